@@ -1,6 +1,8 @@
 package actor
 
 import (
+	"sync"
+	"sync/atomic"
 	"time"
 
 	. "github.com/xeronith/diamante/contracts/actor"
@@ -12,6 +14,7 @@ import (
 )
 
 type actor struct {
+	mutex         sync.RWMutex
 	token         string
 	signature     string
 	remoteAddress string
@@ -51,10 +54,16 @@ func (actor *actor) Signal(code byte) {
 }
 
 func (actor *actor) Token() string {
+	actor.mutex.RLock()
+	defer actor.mutex.RUnlock()
+
 	return actor.token
 }
 
 func (actor *actor) SetToken(token string) {
+	actor.mutex.Lock()
+	defer actor.mutex.Unlock()
+
 	actor.token = token
 	if actor.writer != nil {
 		actor.writer.SetToken(token)
@@ -74,18 +83,30 @@ func (actor *actor) UserAgent() string {
 }
 
 func (actor *actor) Identity() Identity {
+	actor.mutex.RLock()
+	defer actor.mutex.RUnlock()
+
 	return actor.identity
 }
 
 func (actor *actor) SetIdentity(identity Identity) {
+	actor.mutex.Lock()
+	defer actor.mutex.Unlock()
+
 	actor.identity = identity
 }
 
 func (actor *actor) Session() ISystemObject {
+	actor.mutex.RLock()
+	defer actor.mutex.RUnlock()
+
 	return actor.session
 }
 
 func (actor *actor) SetSession(session ISystemObject) {
+	actor.mutex.Lock()
+	defer actor.mutex.Unlock()
+
 	actor.session = session
 }
 
@@ -94,11 +115,11 @@ func (actor *actor) Serializer() ISerializer {
 }
 
 func (actor *actor) LastActivity() int64 {
-	return actor.lastActivity
+	return atomic.LoadInt64(&actor.lastActivity)
 }
 
 func (actor *actor) UpdateLastActivity() {
-	actor.lastActivity = time.Now().UnixNano()
+	atomic.StoreInt64(&actor.lastActivity, time.Now().UnixNano())
 }
 
 func (actor *actor) IsActive() bool {
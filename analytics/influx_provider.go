@@ -113,6 +113,15 @@ func (influxDb *influxDb) SubmitMeasurement(key string, tags Tags, fields Fields
 	}
 }
 
+func (influxDb *influxDb) Flush() {
+	influxDb.Lock()
+	batch := influxDb.batch
+	influxDb.resetBatch()
+	influxDb.Unlock()
+
+	influxDb.flush(batch)
+}
+
 func (influxDb *influxDb) resetBatch() {
 	var err error
 	if influxDb.batch, err = client.NewBatchPoints(client.BatchPointsConfig{Database: influxDb.database}); err != nil {
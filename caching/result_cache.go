@@ -0,0 +1,158 @@
+package caching
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/caching"
+	. "github.com/xeronith/diamante/contracts/operation"
+)
+
+type entry struct {
+	key     string
+	result  IOperationResult
+	expires time.Time
+	tags    []string
+}
+
+type resultCache struct {
+	sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[string]*list.Element
+	order    *list.List
+	tags     map[string]map[string]struct{}
+}
+
+// NewResultCache creates a least-recently-used cache holding at most capacity
+// results. The ttl is applied to entries that are put without their own.
+func NewResultCache(capacity int, ttl time.Duration) IResultCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &resultCache{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element, capacity),
+		order:    list.New(),
+		tags:     make(map[string]map[string]struct{}),
+	}
+}
+
+func (cache *resultCache) Put(key string, result IOperationResult, ttl time.Duration, tags ...string) {
+	if ttl <= 0 {
+		ttl = cache.ttl
+	}
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+
+	cache.Lock()
+	defer cache.Unlock()
+
+	if element, exists := cache.entries[key]; exists {
+		cache.remove(element)
+	}
+
+	cache.entries[key] = cache.order.PushFront(&entry{
+		key:     key,
+		result:  result,
+		expires: expires,
+		tags:    tags,
+	})
+
+	for _, tag := range tags {
+		keys, exists := cache.tags[tag]
+		if !exists {
+			keys = make(map[string]struct{})
+			cache.tags[tag] = keys
+		}
+
+		keys[key] = struct{}{}
+	}
+
+	for cache.order.Len() > cache.capacity {
+		cache.remove(cache.order.Back())
+	}
+}
+
+func (cache *resultCache) Get(key string) (IOperationResult, bool) {
+	cache.Lock()
+	defer cache.Unlock()
+
+	element, exists := cache.entries[key]
+	if !exists {
+		return nil, false
+	}
+
+	_entry := element.Value.(*entry)
+	if !_entry.expires.IsZero() && time.Now().After(_entry.expires) {
+		cache.remove(element)
+		return nil, false
+	}
+
+	cache.order.MoveToFront(element)
+	return _entry.result, true
+}
+
+func (cache *resultCache) Remove(key string) {
+	cache.Lock()
+	defer cache.Unlock()
+
+	if element, exists := cache.entries[key]; exists {
+		cache.remove(element)
+	}
+}
+
+func (cache *resultCache) Invalidate(tags ...string) {
+	cache.Lock()
+	defer cache.Unlock()
+
+	for _, tag := range tags {
+		for key := range cache.tags[tag] {
+			if element, exists := cache.entries[key]; exists {
+				cache.remove(element)
+			}
+		}
+
+		delete(cache.tags, tag)
+	}
+}
+
+func (cache *resultCache) Clear() {
+	cache.Lock()
+	defer cache.Unlock()
+
+	cache.entries = make(map[string]*list.Element, cache.capacity)
+	cache.order.Init()
+	cache.tags = make(map[string]map[string]struct{})
+}
+
+func (cache *resultCache) Size() int {
+	cache.Lock()
+	defer cache.Unlock()
+
+	return cache.order.Len()
+}
+
+func (cache *resultCache) Capacity() int {
+	return cache.capacity
+}
+
+func (cache *resultCache) remove(element *list.Element) {
+	_entry := cache.order.Remove(element).(*entry)
+	delete(cache.entries, _entry.key)
+
+	for _, tag := range _entry.tags {
+		if keys, exists := cache.tags[tag]; exists {
+			delete(keys, _entry.key)
+			if len(keys) == 0 {
+				delete(cache.tags, tag)
+			}
+		}
+	}
+}
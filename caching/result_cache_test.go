@@ -0,0 +1,70 @@
+package caching_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/xeronith/diamante/caching"
+	. "github.com/xeronith/diamante/operation"
+)
+
+func TestResultCache_Capacity(test *testing.T) {
+	cache := NewResultCache(2, 0)
+	cache.Put("A", NewOperationResult(), 0)
+	cache.Put("B", NewOperationResult(), 0)
+
+	if _, exists := cache.Get("A"); !exists {
+		test.FailNow()
+	}
+
+	cache.Put("C", NewOperationResult(), 0)
+
+	if _, exists := cache.Get("B"); exists {
+		test.Fail()
+	}
+
+	if _, exists := cache.Get("A"); !exists {
+		test.Fail()
+	}
+
+	if cache.Size() != 2 {
+		test.Fail()
+	}
+}
+
+func TestResultCache_TTL(test *testing.T) {
+	cache := NewResultCache(10, time.Hour)
+	cache.Put("A", NewOperationResult(), time.Millisecond*10)
+	cache.Put("B", NewOperationResult(), 0)
+
+	time.Sleep(time.Millisecond * 20)
+
+	if _, exists := cache.Get("A"); exists {
+		test.Fail()
+	}
+
+	if _, exists := cache.Get("B"); !exists {
+		test.Fail()
+	}
+}
+
+func TestResultCache_Invalidate(test *testing.T) {
+	cache := NewResultCache(10, 0)
+	cache.Put("A", NewOperationResult(), 0, "users")
+	cache.Put("B", NewOperationResult(), 0, "users", "posts")
+	cache.Put("C", NewOperationResult(), 0, "posts")
+	cache.Put("D", NewOperationResult(), 0)
+
+	cache.Invalidate("users")
+
+	for key, expected := range map[string]bool{"A": false, "B": false, "C": true, "D": true} {
+		if _, exists := cache.Get(key); exists != expected {
+			test.Errorf("%s: expected %v", key, expected)
+		}
+	}
+
+	cache.Clear()
+	if cache.Size() != 0 {
+		test.Fail()
+	}
+}
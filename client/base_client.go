@@ -1,14 +1,24 @@
 package client
 
 import (
+	"errors"
+	"log"
+	"net/http"
+
 	. "github.com/xeronith/diamante/contracts/client"
 	. "github.com/xeronith/diamante/contracts/operation"
 	. "github.com/xeronith/diamante/contracts/serialization"
+	. "github.com/xeronith/diamante/contracts/server"
+	. "github.com/xeronith/diamante/contracts/system"
+	. "github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+	. "github.com/xeronith/diamante/utility/reflection"
 )
 
 type baseClient struct {
 	serializer              ISerializer
 	operationResultListener func(IOperationResult)
+	streamListener          func(IOperationResult)
 	connectionEstablished   func(IClient)
 	endpoint                string
 	token                   string
@@ -25,6 +35,98 @@ func (client *baseClient) SetOperationResultListener(listener func(IOperationRes
 	client.operationResultListener = listener
 }
 
+func (client *baseClient) SetStreamListener(listener func(IOperationResult)) {
+	client.streamListener = listener
+}
+
+// dispatch delivers partial results of streaming operations to the stream
+// listener, if one is set, and everything else to the operation result listener.
+// Batch results are unpacked and their items are delivered one by one.
+func (client *baseClient) dispatch(result IOperationResult) {
+	if result != nil && result.Type() == BATCH_RESULT && result.Status() == http.StatusOK {
+		batch := &protobuf.OperationBatchResult{}
+		if err := client.serializer.Deserialize(result.Payload(), batch); err != nil {
+			log.Println("BATCH RESULT DESERIALIZATION ERROR: ", err)
+			return
+		}
+
+		for _, item := range batch.Results {
+			data, err := client.serializer.Serialize(item)
+			if err != nil {
+				log.Println("BATCH RESULT SERIALIZATION ERROR: ", err)
+				continue
+			}
+
+			operationResult := NewOperationResult()
+			if err := client.serializer.Deserialize(data, operationResult.Container()); err != nil {
+				log.Println("BATCH RESULT DESERIALIZATION ERROR: ", err)
+				continue
+			}
+
+			client.dispatch(operationResult)
+		}
+
+		return
+	}
+
+	if result != nil && result.IsPartial() && client.streamListener != nil {
+		client.streamListener(result)
+		return
+	}
+
+	if client.operationResultListener != nil {
+		client.operationResultListener(result)
+	}
+}
+
 func (client *baseClient) OnConnectionEstablished(callback func(IClient)) {
 	client.connectionEstablished = callback
 }
+
+func (client *baseClient) createRequest(id uint64, operation uint64, payload Pointer) ([]byte, error) {
+	return client.createVersionedRequest(id, operation, client.apiVersion, payload)
+}
+
+func (client *baseClient) createVersionedRequest(id uint64, operation uint64, apiVersion int32, payload Pointer) ([]byte, error) {
+	if !IsPointer(payload) {
+		return nil, errors.New("payload should be a pointer")
+	}
+
+	operationRequest := CreateOperationRequest(id, operation, client.name, client.version, apiVersion, client.token, nil)
+	if err := operationRequest.Load(payload, client.serializer); err != nil {
+		return nil, err
+	}
+
+	return client.serializer.Serialize(operationRequest.Container())
+}
+
+func (client *baseClient) createBatchRequest(id uint64, sequential bool, items ...BatchItem) ([]byte, error) {
+	batch := &protobuf.OperationBatchRequest{
+		Requests:   make([]*protobuf.OperationRequest, 0, len(items)),
+		Sequential: sequential,
+	}
+
+	for _, item := range items {
+		if !IsPointer(item.Payload) {
+			return nil, errors.New("payload should be a pointer")
+		}
+
+		payload, err := client.serializer.Serialize(item.Payload)
+		if err != nil {
+			return nil, err
+		}
+
+		batch.Requests = append(batch.Requests, &protobuf.OperationRequest{
+			Id:        item.Id,
+			Operation: item.Operation,
+			Payload:   payload,
+		})
+	}
+
+	operationRequest := CreateOperationRequest(id, BATCH_REQUEST, client.name, client.version, client.apiVersion, client.token, nil)
+	if err := operationRequest.Load(batch, client.serializer); err != nil {
+		return nil, err
+	}
+
+	return client.serializer.Serialize(operationRequest.Container())
+}
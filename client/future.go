@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/operation"
+)
+
+type future struct {
+	id     uint64
+	done   chan struct{}
+	once   sync.Once
+	result IOperationResult
+	err    error
+	timer  *time.Timer
+	cancel func(error)
+	sent   bool
+}
+
+func newFuture(id uint64) *future {
+	return &future{
+		id:   id,
+		done: make(chan struct{}),
+	}
+}
+
+func (future *future) Id() uint64 {
+	return future.id
+}
+
+func (future *future) Done() <-chan struct{} {
+	return future.done
+}
+
+func (future *future) Result() (IOperationResult, error) {
+	<-future.done
+	return future.result, future.err
+}
+
+func (future *future) Wait(ctx context.Context) (IOperationResult, error) {
+	select {
+	case <-future.done:
+		return future.result, future.err
+	case <-ctx.Done():
+		if future.cancel != nil {
+			future.cancel(ctx.Err())
+		}
+
+		return nil, ctx.Err()
+	}
+}
+
+func (future *future) complete(result IOperationResult, err error) {
+	future.once.Do(func() {
+		if future.timer != nil {
+			future.timer.Stop()
+		}
+
+		future.result, future.err = result, err
+		close(future.done)
+	})
+}
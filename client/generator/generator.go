@@ -0,0 +1,309 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	. "github.com/xeronith/diamante/contracts/operation"
+)
+
+// reserved holds the identifiers of the generated source that the aliases of
+// the message packages must not shadow.
+var reserved = map[string]bool{
+	"client": true, "context": true, "c": true, "ctx": true,
+	"input": true, "output": true, "err": true,
+}
+
+type method struct {
+	Name       string
+	Tag        string
+	Opcode     uint64
+	ResultId   uint64
+	Input      string
+	Output     string
+	Deprecated bool
+	Streaming  bool
+}
+
+type source struct {
+	Package    string
+	ApiVersion int32
+	Imports    map[string]string
+	Methods    []method
+}
+
+var clientTemplate = template.Must(template.New("client").Parse(`// Code generated by the diamante client generator. DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"context"
+
+	"github.com/xeronith/diamante/client"
+	. "github.com/xeronith/diamante/contracts/client"
+{{- range $path, $alias := .Imports }}
+	{{ $alias }} "{{ $path }}"
+{{- end }}
+)
+
+// API_VERSION is the api version the methods of the client are generated for.
+// noinspection GoSnakeCaseUsage
+const API_VERSION = {{ .ApiVersion }}
+
+type Client struct {
+	client IRequestingClient
+}
+
+// NewClient wraps the client. The methods request API_VERSION, whatever the
+// api version set on the client for its other requests.
+func NewClient(client IRequestingClient) *Client {
+	return &Client{client: client}
+}
+
+func (c *Client) Client() IRequestingClient {
+	return c.client
+}
+{{ range .Methods }}
+// {{ .Name }} requests the {{ .Tag }} operation (opcode {{ .Opcode }}).
+{{- if .Streaming }}
+// Its partial results are delivered to the stream listener of the client.
+{{- end }}
+{{- if .Deprecated }}
+//
+// Deprecated: the operation is deprecated by the server.
+{{- end }}
+func (c *Client) {{ .Name }}(ctx context.Context, input *{{ .Input }}) (*{{ .Output }}, error) {
+	output := &{{ .Output }}{}
+	if err := client.Invoke(ctx, c.client, API_VERSION, {{ .Opcode }}, {{ .ResultId }}, input, output); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+{{ end }}`))
+
+// Generate returns the source of a typed client with one method per opcode of
+// the factory, named after the tag of the operation. When an opcode has many
+// versions the one with the highest api versions is used, and the methods
+// request the lowest api version all of them serve, so that the server picks
+// the same versions. The source is meant to be written by a small
+// program run with go generate.
+func Generate(packageName string, factory IOperationFactory) ([]byte, error) {
+	if !token.IsIdentifier(packageName) {
+		return nil, fmt.Errorf("invalid package name: %s", packageName)
+	}
+
+	if factory == nil {
+		return nil, errors.New("nil operation factory")
+	}
+
+	operations := latestVersions(factory.Operations())
+
+	apiVersion, err := commonApiVersion(operations)
+	if err != nil {
+		return nil, err
+	}
+
+	source := &source{
+		Package:    packageName,
+		ApiVersion: apiVersion,
+		Imports:    make(map[string]string),
+		Methods:    make([]method, 0, len(operations)),
+	}
+
+	names := make(map[string]bool)
+	for _, operation := range operations {
+		opcode, resultId := operation.Id()
+
+		input, err := source.typeName(operation.InputContainer())
+		if err != nil {
+			return nil, fmt.Errorf("%s input: %s", operation.Tag(), err)
+		}
+
+		output, err := source.typeName(operation.OutputContainer())
+		if err != nil {
+			return nil, fmt.Errorf("%s output: %s", operation.Tag(), err)
+		}
+
+		name := methodName(operation.Tag(), opcode)
+		if names[name] {
+			name = fmt.Sprintf("%s%d", name, opcode)
+		}
+
+		names[name] = true
+
+		deprecated := false
+		if versioned, ok := operation.(IVersionedOperation); ok {
+			deprecated, _ = versioned.Deprecation()
+		}
+
+		_, streaming := operation.(IStreamingOperation)
+
+		source.Methods = append(source.Methods, method{
+			Name:       name,
+			Tag:        operation.Tag(),
+			Opcode:     opcode,
+			ResultId:   resultId,
+			Input:      input,
+			Output:     output,
+			Deprecated: deprecated,
+			Streaming:  streaming,
+		})
+	}
+
+	var buffer bytes.Buffer
+	if err := clientTemplate.Execute(&buffer, source); err != nil {
+		return nil, err
+	}
+
+	return format.Source(buffer.Bytes())
+}
+
+func latestVersions(operations []IOperation) []IOperation {
+	latest := make(map[uint64]IOperation)
+	for _, operation := range operations {
+		if operation == nil {
+			continue
+		}
+
+		opcode, _ := operation.Id()
+		if current, exists := latest[opcode]; !exists || isNewer(operation, current) {
+			latest[opcode] = operation
+		}
+	}
+
+	result := make([]IOperation, 0, len(latest))
+	for _, operation := range latest {
+		result = append(result, operation)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		left, _ := result[i].Id()
+		right, _ := result[j].Id()
+		return left < right
+	})
+
+	return result
+}
+
+// commonApiVersion returns the lowest api version served by every operation,
+// or an error when their ranges do not overlap.
+func commonApiVersion(operations []IOperation) (int32, error) {
+	var apiVersion int32
+	for _, operation := range operations {
+		if versioned, ok := operation.(IVersionedOperation); ok {
+			if minimum, _ := versioned.ApiVersions(); minimum > apiVersion {
+				apiVersion = minimum
+			}
+		}
+	}
+
+	for _, operation := range operations {
+		if versioned, ok := operation.(IVersionedOperation); ok {
+			if _, maximum := versioned.ApiVersions(); maximum != 0 && maximum < apiVersion {
+				return 0, fmt.Errorf("%s is not served at api version %d", operation.Tag(), apiVersion)
+			}
+		}
+	}
+
+	return apiVersion, nil
+}
+
+func isNewer(operation, current IOperation) bool {
+	versioned, ok := operation.(IVersionedOperation)
+	if !ok {
+		return false
+	}
+
+	currentVersioned, ok := current.(IVersionedOperation)
+	if !ok {
+		return true
+	}
+
+	minimum, _ := versioned.ApiVersions()
+	currentMinimum, _ := currentVersioned.ApiVersions()
+	return minimum > currentMinimum
+}
+
+// typeName returns the qualified name of the message type of the container,
+// registering an alias for its package that is unique within the source.
+func (source *source) typeName(container interface{}) (string, error) {
+	messageType := reflect.TypeOf(container)
+	if messageType == nil || messageType.Kind() != reflect.Ptr || messageType.Elem().Kind() != reflect.Struct {
+		return "", errors.New("container should be a pointer to a struct")
+	}
+
+	messageType = messageType.Elem()
+	if messageType.Name() == "" || messageType.PkgPath() == "" || !token.IsExported(messageType.Name()) {
+		return "", fmt.Errorf("unsupported container type: %s", messageType)
+	}
+
+	alias, exists := source.Imports[messageType.PkgPath()]
+	if !exists {
+		base := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToLower(r)
+			}
+
+			return -1
+		}, path.Base(messageType.PkgPath()))
+
+		if !token.IsIdentifier(base) || reserved[base] {
+			base = "messages"
+		}
+
+		alias = base
+		for index := 2; source.hasAlias(alias); index++ {
+			alias = fmt.Sprintf("%s%d", base, index)
+		}
+
+		source.Imports[messageType.PkgPath()] = alias
+	}
+
+	return alias + "." + messageType.Name(), nil
+}
+
+func (source *source) hasAlias(alias string) bool {
+	for _, existing := range source.Imports {
+		if existing == alias {
+			return true
+		}
+	}
+
+	return false
+}
+
+// methodName turns tags such as GET_PROFILE, get-profile or getProfile into
+// GetProfile. Tags that leave no valid name fall back to the opcode.
+func methodName(tag string, opcode uint64) string {
+	parts := strings.FieldsFunc(tag, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var builder strings.Builder
+	for _, part := range parts {
+		runes := []rune(part)
+		if strings.ToUpper(part) == part {
+			runes = []rune(strings.ToLower(part))
+		}
+
+		runes[0] = unicode.ToUpper(runes[0])
+		builder.WriteString(string(runes))
+	}
+
+	name := builder.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) || name == "Client" {
+		return fmt.Sprintf("Operation%d", opcode)
+	}
+
+	return name
+}
@@ -0,0 +1,95 @@
+package generator_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/xeronith/diamante/client/generator"
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/service"
+	. "github.com/xeronith/diamante/contracts/system"
+	"github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+)
+
+type testOperation struct {
+	operation.Operation
+	tag        string
+	opcode     uint64
+	minimum    int32
+	maximum    int32
+	deprecated bool
+	input      Pointer
+}
+
+func (operation *testOperation) Tag() string              { return operation.tag }
+func (operation *testOperation) Id() (ID, ID)             { return operation.opcode, operation.opcode + 1 }
+func (operation *testOperation) InputContainer() Pointer  { return operation.input }
+func (operation *testOperation) OutputContainer() Pointer { return &protobuf.ServerError{} }
+func (operation *testOperation) ApiVersions() (int32, int32) {
+	return operation.minimum, operation.maximum
+}
+func (operation *testOperation) Deprecation() (bool, time.Time) {
+	return operation.deprecated, time.Time{}
+}
+func (operation *testOperation) Execute(IContext, Pointer) (Pointer, error) {
+	return nil, nil
+}
+
+type factory []IOperation
+
+func (factory factory) Operations() []IOperation { return factory }
+
+func TestGenerate(test *testing.T) {
+	source, err := Generate("api", factory{
+		&testOperation{tag: "GET_PROFILE", opcode: 100, minimum: 1, input: &protobuf.ServerError{}},
+		&testOperation{tag: "GET_PROFILE", opcode: 100, minimum: 2, input: &protobuf.BusMessage{}},
+		&testOperation{tag: "echo", opcode: 200, deprecated: true, input: &protobuf.Resumption{}},
+	})
+
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	code := string(source)
+	for _, expected := range []string{
+		"package api",
+		"const API_VERSION = 2",
+		"func NewClient(client IRequestingClient) *Client {",
+		`protobuf "github.com/xeronith/diamante/protobuf"`,
+		"func (c *Client) GetProfile(ctx context.Context, input *protobuf.BusMessage) (*protobuf.ServerError, error) {",
+		"client.Invoke(ctx, c.client, API_VERSION, 100, 101, input, output)",
+		"// Deprecated:",
+		"func (c *Client) Echo(ctx context.Context, input *protobuf.Resumption) (*protobuf.ServerError, error) {",
+	} {
+		if !strings.Contains(code, expected) {
+			test.Fatalf("missing %q in:\n%s", expected, code)
+		}
+	}
+
+	if strings.Count(code, "func (c *Client) GetProfile") != 1 {
+		test.Fail()
+	}
+}
+
+func TestGenerate_ApiVersionConflict(test *testing.T) {
+	_, err := Generate("api", factory{
+		&testOperation{tag: "A", opcode: 100, minimum: 1, maximum: 1, input: &protobuf.ServerError{}},
+		&testOperation{tag: "B", opcode: 200, minimum: 2, input: &protobuf.ServerError{}},
+	})
+
+	if err == nil {
+		test.Fail()
+	}
+}
+
+func TestGenerate_InvalidContainer(test *testing.T) {
+	if _, err := Generate("api", factory{&testOperation{tag: "X", opcode: 100}}); err == nil {
+		test.Fail()
+	}
+
+	if _, err := Generate("not a package", factory{}); err == nil {
+		test.Fail()
+	}
+}
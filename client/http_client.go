@@ -1,26 +1,29 @@
 package client
 
 import (
+	"bufio"
 	"bytes"
-	"errors"
+	"encoding/base64"
 	"io"
 	"net/http"
 	"net/http/cookiejar"
+	"strings"
+	"sync/atomic"
 
 	. "github.com/xeronith/diamante/contracts/client"
 	. "github.com/xeronith/diamante/contracts/operation"
 	. "github.com/xeronith/diamante/contracts/system"
 	. "github.com/xeronith/diamante/operation"
 	. "github.com/xeronith/diamante/serialization"
-	. "github.com/xeronith/diamante/utility/reflection"
 )
 
 type httpClient struct {
 	baseClient
 	internalClient *http.Client
+	nextId         uint64
 }
 
-func NewHttpClient() IClient {
+func NewHttpClient() IRequestingClient {
 	jar, err := cookiejar.New(nil)
 	if err != nil {
 		panic(err)
@@ -34,7 +37,7 @@ func NewHttpClient() IClient {
 	}
 }
 
-func CreateHttpClient(listener func(IOperationResult)) IClient {
+func CreateHttpClient(listener func(IOperationResult)) IRequestingClient {
 	jar, err := cookiejar.New(nil)
 	if err != nil {
 		panic(err)
@@ -51,7 +54,7 @@ func CreateHttpClient(listener func(IOperationResult)) IClient {
 }
 
 // noinspection GoUnusedExportedFunction
-func CreateDistinctHttpClient(version int32, name string, listener func(IOperationResult)) IClient {
+func CreateDistinctHttpClient(version int32, name string, listener func(IOperationResult)) IRequestingClient {
 	jar, err := cookiejar.New(nil)
 	if err != nil {
 		panic(err)
@@ -102,21 +105,42 @@ func (client *httpClient) Connect(endpoint string, token string) error {
 }
 
 func (client *httpClient) Send(id uint64, operation uint64, payload Pointer) error {
-	if !IsPointer(payload) {
-		return errors.New("payload should be a pointer")
+	data, err := client.createRequest(id, operation, payload)
+	if err != nil {
+		return err
 	}
 
-	var (
-		data []byte
-		err  error
-	)
+	return client.send(data)
+}
 
-	operationRequest := CreateOperationRequest(id, operation, client.name, client.version, client.apiVersion, client.token, nil)
-	if err := operationRequest.Load(payload, client.serializer); err != nil {
-		return err
+// Request posts the operation and returns a future that is already complete
+// with the final result. Partial results of streaming operations go to the
+// stream listener.
+func (client *httpClient) Request(operation uint64, payload Pointer) IFuture {
+	return client.RequestVersion(operation, client.apiVersion, payload)
+}
+
+func (client *httpClient) RequestVersion(operation uint64, apiVersion int32, payload Pointer) IFuture {
+	future := newFuture(atomic.AddUint64(&client.nextId, 1))
+
+	data, err := client.createVersionedRequest(future.id, operation, apiVersion, payload)
+	if err != nil {
+		future.complete(nil, err)
+		return future
 	}
 
-	data, err = client.serializer.Serialize(operationRequest.Container())
+	result, err := client.exchange(data, func(result IOperationResult) {
+		if result != nil && result.IsPartial() {
+			client.dispatch(result)
+		}
+	})
+
+	future.complete(result, err)
+	return future
+}
+
+func (client *httpClient) SendBatch(id uint64, sequential bool, items ...BatchItem) error {
+	data, err := client.createBatchRequest(id, sequential, items...)
 	if err != nil {
 		return err
 	}
@@ -125,32 +149,75 @@ func (client *httpClient) Send(id uint64, operation uint64, payload Pointer) err
 }
 
 func (client *httpClient) send(data []byte) error {
+	_, err := client.exchange(data, client.dispatch)
+	return err
+}
+
+// exchange posts the request and hands every result it receives to the
+// handler, returning the final one.
+func (client *httpClient) exchange(data []byte, handler func(IOperationResult)) (IOperationResult, error) {
 	request, err := http.NewRequest("POST", client.endpoint, bytes.NewBuffer(data))
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	response, err := client.internalClient.Do(request)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	defer response.Body.Close()
+	if strings.HasPrefix(response.Header.Get("Content-Type"), "text/event-stream") {
+		return client.receiveStream(response.Body, handler)
 	}
 
 	buffer, err := io.ReadAll(response.Body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	operationResult := NewOperationResult()
 	err = client.serializer.Deserialize(buffer, operationResult.Container())
 	if err != nil {
-		client.operationResultListener(nil)
-		return err
+		handler(nil)
+		return nil, err
 	}
 
-	client.operationResultListener(operationResult)
+	handler(operationResult)
 
-	return nil
+	return operationResult, nil
+}
+
+// receiveStream reads the server-sent events of a streaming operation until
+// the 'end' event that carries the final result.
+func (client *httpClient) receiveStream(body io.Reader, handler func(IOperationResult)) (IOperationResult, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, "data: "))
+		if err != nil {
+			return nil, err
+		}
+
+		operationResult := NewOperationResult()
+		if err := client.serializer.Deserialize(data, operationResult.Container()); err != nil {
+			return nil, err
+		}
+
+		handler(operationResult)
+		if !operationResult.IsPartial() {
+			return operationResult, nil
+		}
+	}
+
+	return nil, scanner.Err()
 }
 
 func (client *httpClient) Disconnect() error {
@@ -0,0 +1,128 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/xeronith/diamante/client"
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/service"
+	. "github.com/xeronith/diamante/contracts/system"
+	"github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server/servertest"
+)
+
+type streamOperation struct {
+	operation.StreamingOperation
+}
+
+func (operation *streamOperation) Tag() string              { return "STREAM" }
+func (operation *streamOperation) Id() (ID, ID)             { return 100, 101 }
+func (operation *streamOperation) InputContainer() Pointer  { return &protobuf.ServerError{} }
+func (operation *streamOperation) OutputContainer() Pointer { return &protobuf.ServerError{} }
+func (operation *streamOperation) ExecuteStream(context IStreamContext, payload Pointer) error {
+	for _, suffix := range []string{"1", "2"} {
+		if err := context.Emit(&protobuf.ServerError{Message: payload.(*protobuf.ServerError).Message + suffix}); err != nil {
+			return err
+		}
+	}
+
+	context.Complete()
+	return nil
+}
+
+type versionedOperation struct {
+	operation.Operation
+	minimum int32
+	maximum int32
+}
+
+func (operation *versionedOperation) Tag() string              { return "VERSIONED" }
+func (operation *versionedOperation) Id() (ID, ID)             { return 200, 201 }
+func (operation *versionedOperation) InputContainer() Pointer  { return &protobuf.ServerError{} }
+func (operation *versionedOperation) OutputContainer() Pointer { return &protobuf.ServerError{} }
+func (operation *versionedOperation) ApiVersions() (int32, int32) {
+	return operation.minimum, operation.maximum
+}
+func (operation *versionedOperation) Deprecation() (bool, time.Time) { return false, time.Time{} }
+func (operation *versionedOperation) Execute(_ IContext, _ Pointer) (Pointer, error) {
+	return &protobuf.ServerError{Message: fmt.Sprintf("v%d", operation.minimum)}, nil
+}
+
+func TestHttpClient_RequestVersion(test *testing.T) {
+	harness := servertest.NewHarness(test, &versionedOperation{minimum: 1, maximum: 1}, &versionedOperation{minimum: 2})
+	identity := harness.NewIdentity(1, USER)
+
+	harness.Serve()
+
+	client := CreateHttpClient(nil)
+	client.SetApiVersion(1)
+	if err := client.Connect(harness.PassiveEndpoint(), identity.Token()); err != nil {
+		test.Fatal(err)
+	}
+
+	// Each request asks for its own api version, leaving the one set on the
+	// client to the other requests.
+	for _, apiVersion := range []int32{2, 1} {
+		output := &protobuf.ServerError{}
+		if err := Invoke(context.Background(), client, apiVersion, 200, 201, &protobuf.ServerError{}, output); err != nil || output.Message != fmt.Sprintf("v%d", apiVersion) {
+			test.Fatal(apiVersion, err, output.Message)
+		}
+	}
+
+	result, err := client.Request(200, &protobuf.ServerError{}).Result()
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	output := &protobuf.ServerError{}
+	if err := client.Serializer().Deserialize(result.Payload(), output); err != nil || output.Message != "v1" {
+		test.Fatal(err, output.Message)
+	}
+}
+
+func TestHttpClient_Stream(test *testing.T) {
+	harness := servertest.NewHarness(test, &streamOperation{})
+	identity := harness.NewIdentity(1, USER)
+
+	harness.Serve()
+
+	client := CreateHttpClient(nil)
+
+	var mutex sync.Mutex
+	messages := make([]string, 0)
+	client.SetStreamListener(func(result IOperationResult) {
+		output := &protobuf.ServerError{}
+		if err := client.Serializer().Deserialize(result.Payload(), output); err != nil {
+			test.Error(err)
+		}
+
+		mutex.Lock()
+		messages = append(messages, output.Message)
+		mutex.Unlock()
+	})
+
+	if err := client.Connect(harness.PassiveEndpoint(), identity.Token()); err != nil {
+		test.Fatal(err)
+	}
+
+	result, err := client.Request(100, &protobuf.ServerError{Message: "stream"}).Result()
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if result.IsPartial() || result.Status() != 200 {
+		test.Fatal(result.Status(), result.IsPartial())
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(messages) != 2 || messages[0] != "stream1" || messages[1] != "stream2" {
+		test.Fatal(messages)
+	}
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/xeronith/diamante/contracts/client"
+	. "github.com/xeronith/diamante/contracts/system"
+	"github.com/xeronith/diamante/protobuf"
+)
+
+var UNEXPECTED_RESULT_TYPE = errors.New("unexpected_result_type")
+
+// Invoke requests the api version of the operation with the input and loads
+// its result into the output. Error results are returned as errors with the
+// description of the ServerError, or its message when it has no description,
+// the same way network/http.Handle reports them.
+func Invoke(ctx context.Context, client IRequestingClient, apiVersion int32, operation, resultType uint64, input, output Pointer) error {
+	result, err := client.RequestVersion(operation, apiVersion, input).Wait(ctx)
+	if err != nil {
+		return err
+	}
+
+	if result.Type() != resultType {
+		if result.Type() != 0 {
+			return UNEXPECTED_RESULT_TYPE
+		}
+
+		serverError := &protobuf.ServerError{}
+		if err := client.Serializer().Deserialize(result.Payload(), serverError); err != nil {
+			return err
+		}
+
+		if serverError.Description != "" {
+			return errors.New(serverError.Description)
+		} else {
+			return errors.New(serverError.Message)
+		}
+	}
+
+	return client.Serializer().Deserialize(result.Payload(), output)
+}
@@ -0,0 +1,548 @@
+package client
+
+import (
+	"crypto/tls"
+	"errors"
+	"log"
+	"math/rand"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	. "github.com/xeronith/diamante/contracts/client"
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/serialization"
+	. "github.com/xeronith/diamante/contracts/server"
+	. "github.com/xeronith/diamante/contracts/system"
+	. "github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+	. "github.com/xeronith/diamante/serialization"
+)
+
+const (
+	DEFAULT_CLIENT_MIN_BACKOFF         = time.Millisecond * 500
+	DEFAULT_CLIENT_MAX_BACKOFF         = time.Second * 30
+	DEFAULT_CLIENT_REQUEST_TIMEOUT     = time.Second * 30
+	DEFAULT_CLIENT_OFFLINE_BUFFER_SIZE = 256
+	CLIENT_HEARTBEAT_INTERVAL          = time.Second * 15
+	CLIENT_WRITE_TIMEOUT               = time.Second * 4
+	CLIENT_HANDSHAKE_TIMEOUT           = time.Second * 10
+	CLIENT_REQUEST_ID_BASE             = uint64(1) << 32
+)
+
+var (
+	CLIENT_CLOSED              = errors.New("client_closed")
+	CLIENT_OFFLINE_BUFFER_FULL = errors.New("client_offline_buffer_full")
+	CLIENT_REQUEST_TIMEOUT     = errors.New("client_request_timeout")
+	CLIENT_CONNECTION_LOST     = errors.New("client_connection_lost")
+)
+
+type reconnectingWebSocketClient struct {
+	mutex          sync.Mutex
+	base           baseClient
+	tlsConfig      *tls.Config
+	minBackoff     time.Duration
+	maxBackoff     time.Duration
+	requestTimeout time.Duration
+	bufferSize     int
+	buffer         []bufferedRequest
+	connection     *websocket.Conn
+	resumeToken    string
+	closed         bool
+	done           chan struct{}
+	nextId         uint64
+	pendingMutex   sync.Mutex
+	pending        map[uint64]*future
+}
+
+type bufferedRequest struct {
+	id   uint64
+	data []byte
+}
+
+// NewReconnectingWebSocketClient creates a client that keeps its connection
+// alive. Dropped connections are dialed again with an exponential backoff and
+// resumed when the server supports resumption. Requests sent while offline
+// are buffered and flushed in order once the connection is back. Requests
+// already sent when the connection drops fail with CLIENT_CONNECTION_LOST,
+// since there is no telling whether the server has executed them.
+func NewReconnectingWebSocketClient() IReconnectingClient {
+	return &reconnectingWebSocketClient{
+		base:           baseClient{},
+		minBackoff:     DEFAULT_CLIENT_MIN_BACKOFF,
+		maxBackoff:     DEFAULT_CLIENT_MAX_BACKOFF,
+		requestTimeout: DEFAULT_CLIENT_REQUEST_TIMEOUT,
+		bufferSize:     DEFAULT_CLIENT_OFFLINE_BUFFER_SIZE,
+		buffer:         make([]bufferedRequest, 0),
+		nextId:         CLIENT_REQUEST_ID_BASE,
+		pending:        make(map[uint64]*future),
+	}
+}
+
+func CreateReconnectingWebSocketClient(listener func(IOperationResult)) IReconnectingClient {
+	client := NewReconnectingWebSocketClient().(*reconnectingWebSocketClient)
+	client.base.operationResultListener = listener
+	return client
+}
+
+func (client *reconnectingWebSocketClient) IsActive() bool {
+	return true
+}
+
+func (client *reconnectingWebSocketClient) IsConnected() bool {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	return client.connection != nil
+}
+
+func (client *reconnectingWebSocketClient) SetName(name string) {
+	client.base.name = name
+}
+
+func (client *reconnectingWebSocketClient) SetToken(token string) {
+	client.base.token = token
+}
+
+func (client *reconnectingWebSocketClient) SetVersion(version int32) {
+	client.base.version = version
+}
+
+func (client *reconnectingWebSocketClient) SetApiVersion(apiVersion int32) {
+	client.base.apiVersion = apiVersion
+}
+
+// SetTLSConfig sets the configuration of wss connections. Certificates are
+// verified against the system roots when no configuration is set.
+func (client *reconnectingWebSocketClient) SetTLSConfig(config *tls.Config) {
+	client.tlsConfig = config
+}
+
+func (client *reconnectingWebSocketClient) SetBackoff(minBackoff, maxBackoff time.Duration) {
+	if minBackoff <= 0 {
+		minBackoff = DEFAULT_CLIENT_MIN_BACKOFF
+	}
+
+	if maxBackoff < minBackoff {
+		maxBackoff = minBackoff
+	}
+
+	client.minBackoff, client.maxBackoff = minBackoff, maxBackoff
+}
+
+// SetRequestTimeout sets how long Request waits for the result before the
+// future fails with CLIENT_REQUEST_TIMEOUT. Zero waits indefinitely.
+func (client *reconnectingWebSocketClient) SetRequestTimeout(timeout time.Duration) {
+	client.requestTimeout = timeout
+}
+
+// SetOfflineBufferSize sets how many requests are kept while the client is
+// offline. Sends fail with CLIENT_OFFLINE_BUFFER_FULL once the buffer is full.
+func (client *reconnectingWebSocketClient) SetOfflineBufferSize(size int) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	client.bufferSize = size
+}
+
+func (client *reconnectingWebSocketClient) Serializer() ISerializer {
+	return client.base.serializer
+}
+
+func (client *reconnectingWebSocketClient) SetOperationResultListener(listener func(IOperationResult)) {
+	client.base.operationResultListener = listener
+}
+
+func (client *reconnectingWebSocketClient) SetStreamListener(listener func(IOperationResult)) {
+	client.base.streamListener = listener
+}
+
+// OnConnectionEstablished sets a callback that is called after every
+// successful connection, including the reconnections.
+func (client *reconnectingWebSocketClient) OnConnectionEstablished(callback func(IClient)) {
+	client.base.connectionEstablished = callback
+}
+
+// Connect dials the endpoint and keeps the connection alive until Disconnect
+// is called. Only the first dial is reported; later failures are retried.
+func (client *reconnectingWebSocketClient) Connect(endpoint string, token string) error {
+	client.base.token = token
+	client.base.endpoint = endpoint
+	client.base.serializer = NewProtobufSerializer()
+
+	client.mutex.Lock()
+	client.closed = false
+	client.done = make(chan struct{})
+	client.mutex.Unlock()
+
+	connection, err := client.dial()
+	if err != nil {
+		return err
+	}
+
+	go client.run(connection)
+	return nil
+}
+
+func (client *reconnectingWebSocketClient) Send(id uint64, operation uint64, payload Pointer) error {
+	data, err := client.base.createRequest(id, operation, payload)
+	if err != nil {
+		return err
+	}
+
+	return client.write(id, data)
+}
+
+func (client *reconnectingWebSocketClient) SendBatch(id uint64, sequential bool, items ...BatchItem) error {
+	data, err := client.base.createBatchRequest(id, sequential, items...)
+	if err != nil {
+		return err
+	}
+
+	return client.write(id, data)
+}
+
+// Request sends the operation with an id of its own and returns a future of
+// the result with the same id. Partial results of streaming operations go to
+// the stream listener and the future completes with the final one. The ids
+// start above CLIENT_REQUEST_ID_BASE so they don't collide with the ids used
+// with Send. A future whose Wait is cancelled is discarded, and the result,
+// if it arrives, goes to the operation result listener.
+func (client *reconnectingWebSocketClient) Request(operation uint64, payload Pointer) IFuture {
+	return client.RequestVersion(operation, client.base.apiVersion, payload)
+}
+
+func (client *reconnectingWebSocketClient) RequestVersion(operation uint64, apiVersion int32, payload Pointer) IFuture {
+	future := newFuture(atomic.AddUint64(&client.nextId, 1))
+	future.cancel = func(err error) {
+		client.resolve(future.id, nil, err)
+	}
+
+	client.pendingMutex.Lock()
+	client.pending[future.id] = future
+	if client.requestTimeout > 0 {
+		future.timer = time.AfterFunc(client.requestTimeout, func() {
+			client.resolve(future.id, nil, CLIENT_REQUEST_TIMEOUT)
+		})
+	}
+	client.pendingMutex.Unlock()
+
+	data, err := client.base.createVersionedRequest(future.id, operation, apiVersion, payload)
+	if err == nil {
+		err = client.write(future.id, data)
+	}
+
+	if err != nil {
+		client.resolve(future.id, nil, err)
+	}
+
+	return future
+}
+
+func (client *reconnectingWebSocketClient) Disconnect() error {
+	client.mutex.Lock()
+	if client.closed {
+		client.mutex.Unlock()
+		return nil
+	}
+
+	client.closed = true
+	if client.done != nil {
+		close(client.done)
+	}
+
+	connection := client.connection
+	client.connection = nil
+	client.buffer = make([]bufferedRequest, 0)
+	client.mutex.Unlock()
+
+	client.pendingMutex.Lock()
+	pending := client.pending
+	client.pending = make(map[uint64]*future)
+	client.pendingMutex.Unlock()
+
+	for _, future := range pending {
+		future.complete(nil, CLIENT_CLOSED)
+	}
+
+	if connection == nil {
+		return nil
+	}
+
+	message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	err := connection.WriteControl(websocket.CloseMessage, message, time.Now().Add(CLIENT_WRITE_TIMEOUT))
+	_ = connection.Close()
+
+	return err
+}
+
+func (client *reconnectingWebSocketClient) dial() (*websocket.Conn, error) {
+	endpoint, err := url.Parse(client.base.endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	client.mutex.Lock()
+	if client.resumeToken != "" {
+		query := endpoint.Query()
+		query.Set("resume", client.resumeToken)
+		endpoint.RawQuery = query.Encode()
+	}
+	client.mutex.Unlock()
+
+	dialer := &websocket.Dialer{
+		Proxy:            websocket.DefaultDialer.Proxy,
+		HandshakeTimeout: CLIENT_HANDSHAKE_TIMEOUT,
+		TLSClientConfig:  client.tlsConfig,
+	}
+
+	connection, _, err := dialer.Dial(endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := CLIENT_HEARTBEAT_INTERVAL * 2
+	_ = connection.SetReadDeadline(time.Now().Add(timeout))
+	connection.SetPongHandler(func(string) error {
+		return connection.SetReadDeadline(time.Now().Add(timeout))
+	})
+
+	connection.SetPingHandler(func(data string) error {
+		_ = connection.SetReadDeadline(time.Now().Add(timeout))
+		err := connection.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(CLIENT_WRITE_TIMEOUT))
+		if errors.Is(err, websocket.ErrCloseSent) {
+			return nil
+		}
+
+		return err
+	})
+
+	if err := client.attach(connection); err != nil {
+		_ = connection.Close()
+		return nil, err
+	}
+
+	if client.base.connectionEstablished != nil {
+		go client.base.connectionEstablished(client)
+	}
+
+	return connection, nil
+}
+
+// attach makes the connection current and flushes the offline buffer through
+// it, holding the lock so that new sends queue up behind the buffered ones.
+func (client *reconnectingWebSocketClient) attach(connection *websocket.Conn) error {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if client.closed {
+		return CLIENT_CLOSED
+	}
+
+	for len(client.buffer) > 0 {
+		_ = connection.SetWriteDeadline(time.Now().Add(CLIENT_WRITE_TIMEOUT))
+		if err := connection.WriteMessage(websocket.BinaryMessage, client.buffer[0].data); err != nil {
+			return err
+		}
+
+		client.markSent(client.buffer[0].id)
+		client.buffer = client.buffer[1:]
+	}
+
+	client.connection = connection
+	return nil
+}
+
+func (client *reconnectingWebSocketClient) write(id uint64, data []byte) error {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if client.closed {
+		return CLIENT_CLOSED
+	}
+
+	if client.connection != nil {
+		_ = client.connection.SetWriteDeadline(time.Now().Add(CLIENT_WRITE_TIMEOUT))
+		if err := client.connection.WriteMessage(websocket.BinaryMessage, data); err == nil {
+			client.markSent(id)
+			return nil
+		}
+
+		// The read loop notices the broken connection and reconnects.
+		_ = client.connection.Close()
+		client.connection = nil
+	}
+
+	if len(client.buffer) >= client.bufferSize {
+		return CLIENT_OFFLINE_BUFFER_FULL
+	}
+
+	client.buffer = append(client.buffer, bufferedRequest{id: id, data: data})
+	return nil
+}
+
+// markSent records that the request of the pending future has been written
+// to the current connection, so that it fails if the connection drops.
+func (client *reconnectingWebSocketClient) markSent(id uint64) {
+	client.pendingMutex.Lock()
+	defer client.pendingMutex.Unlock()
+
+	if future, exists := client.pending[id]; exists {
+		future.sent = true
+	}
+}
+
+// failSent fails the pending futures whose requests were written to a
+// connection that has dropped. The buffered ones are kept.
+func (client *reconnectingWebSocketClient) failSent(err error) {
+	client.pendingMutex.Lock()
+	failed := make([]*future, 0)
+	for id, future := range client.pending {
+		if future.sent {
+			failed = append(failed, future)
+			delete(client.pending, id)
+		}
+	}
+	client.pendingMutex.Unlock()
+
+	for _, future := range failed {
+		future.complete(nil, err)
+	}
+}
+
+func (client *reconnectingWebSocketClient) run(connection *websocket.Conn) {
+	for {
+		client.receive(connection)
+
+		client.mutex.Lock()
+		if client.connection == connection {
+			client.connection = nil
+		}
+		closed, done := client.closed, client.done
+		client.mutex.Unlock()
+
+		if closed {
+			return
+		}
+
+		client.failSent(CLIENT_CONNECTION_LOST)
+
+		connection = client.reconnect(done)
+		if connection == nil {
+			return
+		}
+	}
+}
+
+func (client *reconnectingWebSocketClient) reconnect(done chan struct{}) *websocket.Conn {
+	backoff := client.minBackoff
+	for attempt := 1; ; attempt++ {
+		jitter := time.Duration(float64(backoff) * 0.2 * (rand.Float64()*2 - 1))
+		select {
+		case <-done:
+			return nil
+		case <-time.After(backoff + jitter):
+		}
+
+		connection, err := client.dial()
+		if err == nil {
+			return connection
+		}
+
+		if errors.Is(err, CLIENT_CLOSED) {
+			return nil
+		}
+
+		log.Printf("CLIENT RECONNECT ATTEMPT %d FAILED: %s\n", attempt, err)
+
+		if backoff *= 2; backoff > client.maxBackoff {
+			backoff = client.maxBackoff
+		}
+	}
+}
+
+func (client *reconnectingWebSocketClient) receive(connection *websocket.Conn) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		ticker := time.NewTicker(CLIENT_HEARTBEAT_INTERVAL)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := connection.WriteControl(websocket.PingMessage, nil, time.Now().Add(CLIENT_WRITE_TIMEOUT)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		_, message, err := connection.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure) && client.isOpen() {
+				log.Println("CLIENT SOCKET READ ERROR: ", err)
+			}
+
+			_ = connection.Close()
+			return
+		}
+
+		_ = connection.SetReadDeadline(time.Now().Add(CLIENT_HEARTBEAT_INTERVAL * 2))
+
+		operationResult := NewOperationResult()
+		if err := client.base.serializer.Deserialize(message, operationResult.Container()); err != nil {
+			log.Println("SOCKET DATA DESERIALIZATION ERROR: ", err)
+			continue
+		}
+
+		client.handle(operationResult)
+	}
+}
+
+func (client *reconnectingWebSocketClient) handle(result IOperationResult) {
+	if result.Type() == RESUMPTION_RESULT {
+		resumption := &protobuf.Resumption{}
+		if err := client.base.serializer.Deserialize(result.Payload(), resumption); err != nil {
+			log.Println("RESUMPTION DESERIALIZATION ERROR: ", err)
+			return
+		}
+
+		client.mutex.Lock()
+		client.resumeToken = resumption.Token
+		client.mutex.Unlock()
+		return
+	}
+
+	if !result.IsPartial() && client.resolve(result.Id(), result, nil) {
+		return
+	}
+
+	client.base.dispatch(result)
+}
+
+func (client *reconnectingWebSocketClient) resolve(id uint64, result IOperationResult, err error) bool {
+	client.pendingMutex.Lock()
+	future, exists := client.pending[id]
+	delete(client.pending, id)
+	client.pendingMutex.Unlock()
+
+	if exists {
+		future.complete(result, err)
+	}
+
+	return exists
+}
+
+func (client *reconnectingWebSocketClient) isOpen() bool {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	return !client.closed
+}
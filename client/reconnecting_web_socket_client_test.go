@@ -0,0 +1,342 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	. "github.com/xeronith/diamante/client"
+	. "github.com/xeronith/diamante/contracts/client"
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/server"
+	"github.com/xeronith/diamante/protobuf"
+	"google.golang.org/protobuf/proto"
+)
+
+const AWAIT_TIMEOUT = 5 * time.Second
+
+type receivedRequest struct {
+	*protobuf.OperationRequest
+	connection *websocket.Conn
+}
+
+// socketServer sends a resumption token on every connection and echoes the
+// payloads of the requests it receives, unless it is told to hold them. It
+// can drop its connections and refuse new ones.
+type socketServer struct {
+	*httptest.Server
+	test        *testing.T
+	mutex       sync.Mutex
+	connections []*websocket.Conn
+	attempts    []time.Time
+	resumes     []string
+	refuse      bool
+	hold        bool
+	requests    chan receivedRequest
+}
+
+func newSocketServer(test *testing.T) *socketServer {
+	server := &socketServer{test: test, requests: make(chan receivedRequest, 64)}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serve))
+	test.Cleanup(server.Close)
+	return server
+}
+
+func (server *socketServer) serve(writer http.ResponseWriter, request *http.Request) {
+	server.mutex.Lock()
+	server.attempts = append(server.attempts, time.Now())
+	if server.refuse {
+		server.mutex.Unlock()
+		writer.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	server.mutex.Unlock()
+
+	connection, err := (&websocket.Upgrader{}).Upgrade(writer, request, nil)
+	if err != nil {
+		server.test.Error(err)
+		return
+	}
+
+	defer connection.Close()
+
+	server.mutex.Lock()
+	server.connections = append(server.connections, connection)
+	server.resumes = append(server.resumes, request.URL.Query().Get("resume"))
+	token := fmt.Sprintf("token%d", len(server.connections))
+	server.mutex.Unlock()
+
+	payload, _ := proto.Marshal(&protobuf.Resumption{Token: token})
+	server.send(connection, &protobuf.OperationResult{Status: 200, Type: RESUMPTION_RESULT, Payload: payload})
+
+	for {
+		_, data, err := connection.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		received := receivedRequest{&protobuf.OperationRequest{}, connection}
+		if err := proto.Unmarshal(data, received.OperationRequest); err != nil {
+			server.test.Error(err)
+			return
+		}
+
+		server.mutex.Lock()
+		hold := server.hold
+		server.mutex.Unlock()
+
+		server.requests <- received
+		if !hold {
+			server.answer(received)
+		}
+	}
+}
+
+func (server *socketServer) send(connection *websocket.Conn, result *protobuf.OperationResult) {
+	data, _ := proto.Marshal(result)
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	_ = connection.WriteMessage(websocket.BinaryMessage, data)
+}
+
+func (server *socketServer) answer(request receivedRequest) {
+	server.send(request.connection, &protobuf.OperationResult{
+		Id:      request.Id,
+		Status:  200,
+		Type:    request.Operation + 1,
+		Payload: request.Payload,
+	})
+}
+
+func (server *socketServer) endpoint() string {
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func (server *socketServer) set(refuse, hold bool) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.refuse, server.hold = refuse, hold
+}
+
+// drop closes the connections, refusing new ones until set is called again.
+func (server *socketServer) drop() {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.refuse = true
+	for _, connection := range server.connections {
+		_ = connection.Close()
+	}
+}
+
+func (server *socketServer) receive() receivedRequest {
+	server.test.Helper()
+
+	select {
+	case request := <-server.requests:
+		return request
+	case <-time.After(AWAIT_TIMEOUT):
+		server.test.Fatal("no request received")
+		return receivedRequest{}
+	}
+}
+
+func await(test *testing.T, condition func() bool) {
+	test.Helper()
+
+	deadline := time.Now().Add(AWAIT_TIMEOUT)
+	for !condition() {
+		if time.Now().After(deadline) {
+			test.Fatal("condition not met")
+		}
+
+		time.Sleep(time.Millisecond * 5)
+	}
+}
+
+func connect(test *testing.T, server *socketServer, listener func(IOperationResult)) IReconnectingClient {
+	test.Helper()
+
+	client := CreateReconnectingWebSocketClient(listener)
+	client.SetBackoff(time.Millisecond*20, time.Millisecond*200)
+	if err := client.Connect(server.endpoint(), ""); err != nil {
+		test.Fatal(err)
+	}
+
+	test.Cleanup(func() { _ = client.Disconnect() })
+	return client
+}
+
+func echo(test *testing.T, client IReconnectingClient, message string) {
+	test.Helper()
+
+	result, err := client.Request(100, &protobuf.ServerError{Message: message}).Result()
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	output := &protobuf.ServerError{}
+	if err := client.Serializer().Deserialize(result.Payload(), output); err != nil || result.Type() != 101 || output.Message != message {
+		test.Fatal(err, result.Type(), output.Message)
+	}
+}
+
+func TestReconnectingWebSocketClient_Reconnect(test *testing.T) {
+	server := newSocketServer(test)
+	client := connect(test, server, nil)
+
+	// The result follows the resumption token on the same connection.
+	echo(test, client, "first")
+	server.receive()
+
+	server.drop()
+	await(test, func() bool { return !client.IsConnected() })
+
+	// Reconnection attempts are spaced out more and more.
+	await(test, func() bool {
+		server.mutex.Lock()
+		defer server.mutex.Unlock()
+
+		return len(server.attempts) >= 4
+	})
+
+	server.mutex.Lock()
+	attempts := server.attempts
+	server.mutex.Unlock()
+
+	if first, last := attempts[2].Sub(attempts[1]), attempts[3].Sub(attempts[2]); last <= first {
+		test.Fatal(first, last)
+	}
+
+	server.set(false, false)
+	await(test, client.IsConnected)
+
+	echo(test, client, "second")
+	server.receive()
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if len(server.resumes) != 2 || server.resumes[0] != "" || server.resumes[1] != "token1" {
+		test.Fatal(server.resumes)
+	}
+}
+
+func TestReconnectingWebSocketClient_OfflineBuffer(test *testing.T) {
+	server := newSocketServer(test)
+	client := connect(test, server, func(IOperationResult) {})
+	client.SetOfflineBufferSize(3)
+
+	server.drop()
+	await(test, func() bool { return !client.IsConnected() })
+
+	for id := uint64(1); id <= 3; id++ {
+		if err := client.Send(id, 100, &protobuf.ServerError{}); err != nil {
+			test.Fatal(err)
+		}
+	}
+
+	if err := client.Send(4, 100, &protobuf.ServerError{}); err != CLIENT_OFFLINE_BUFFER_FULL {
+		test.Fatal(err)
+	}
+
+	server.set(false, false)
+	for id := uint64(1); id <= 3; id++ {
+		if request := server.receive(); request.Id != id {
+			test.Fatal(id, request.Id)
+		}
+	}
+}
+
+func TestReconnectingWebSocketClient_ConnectionLost(test *testing.T) {
+	server := newSocketServer(test)
+	client := connect(test, server, nil)
+
+	server.set(false, true)
+	sent := client.Request(100, &protobuf.ServerError{Message: "sent"})
+	server.receive()
+
+	server.drop()
+	await(test, func() bool { return !client.IsConnected() })
+
+	// Requests buffered while offline are sent after reconnecting, while the
+	// ones in flight on the dropped connection fail.
+	buffered := client.Request(100, &protobuf.ServerError{Message: "buffered"})
+
+	if _, err := sent.Result(); err != CLIENT_CONNECTION_LOST {
+		test.Fatal(err)
+	}
+
+	server.set(false, false)
+	if result, err := buffered.Result(); err != nil || result.Id() != buffered.Id() {
+		test.Fatal(err)
+	}
+}
+
+func TestReconnectingWebSocketClient_Timeout(test *testing.T) {
+	server := newSocketServer(test)
+
+	results := make(chan IOperationResult, 1)
+	client := connect(test, server, func(result IOperationResult) { results <- result })
+	client.SetRequestTimeout(time.Millisecond * 50)
+
+	server.set(false, true)
+	if _, err := client.Request(100, &protobuf.ServerError{}).Result(); err != CLIENT_REQUEST_TIMEOUT {
+		test.Fatal(err)
+	}
+
+	server.receive()
+
+	// A cancelled wait discards the future, and the result that arrives
+	// later goes to the listener.
+	client.SetRequestTimeout(0)
+	future := client.Request(100, &protobuf.ServerError{})
+	request := server.receive()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+
+	if _, err := future.Wait(ctx); err != context.DeadlineExceeded {
+		test.Fatal(err)
+	}
+
+	server.answer(request)
+	select {
+	case result := <-results:
+		if result.Id() != future.Id() {
+			test.Fatal(result.Id())
+		}
+	case <-time.After(AWAIT_TIMEOUT):
+		test.Fatal("result not dispatched")
+	}
+}
+
+func TestReconnectingWebSocketClient_Disconnect(test *testing.T) {
+	server := newSocketServer(test)
+	client := connect(test, server, nil)
+
+	server.set(false, true)
+	future := client.Request(100, &protobuf.ServerError{})
+	server.receive()
+
+	if err := client.Disconnect(); err != nil {
+		test.Fatal(err)
+	}
+
+	if _, err := future.Result(); err != CLIENT_CLOSED {
+		test.Fatal(err)
+	}
+
+	if err := client.Send(1, 100, &protobuf.ServerError{}); err != CLIENT_CLOSED {
+		test.Fatal(err)
+	}
+}
@@ -77,6 +77,10 @@ func (client *webSocketClient) SetOperationResultListener(listener func(IOperati
 	client.base.operationResultListener = listener
 }
 
+func (client *webSocketClient) SetStreamListener(listener func(IOperationResult)) {
+	client.base.streamListener = listener
+}
+
 func (client *webSocketClient) OnConnectionEstablished(callback func(IClient)) {
 	client.base.connectionEstablished = callback
 }
@@ -150,13 +154,13 @@ func (client *webSocketClient) Connect(endpoint string, token string) error {
 				return
 			}
 
-			if client.base.operationResultListener != nil {
+			if client.base.operationResultListener != nil || client.base.streamListener != nil {
 				operationResult := NewOperationResult()
 				err = client.base.serializer.Deserialize(message, operationResult.Container())
 				if err != nil {
 					log.Println("SOCKET DATA DESERIALIZATION ERROR: ", err)
 				} else {
-					client.base.operationResultListener(operationResult)
+					client.base.dispatch(operationResult)
 				}
 			}
 		}
@@ -195,6 +199,18 @@ func (client *webSocketClient) Send(id uint64, operation uint64, payload Pointer
 	return client.connection.WriteMessage(websocket.TextMessage, data)
 }
 
+func (client *webSocketClient) SendBatch(id uint64, sequential bool, items ...BatchItem) error {
+	data, err := client.base.createBatchRequest(id, sequential, items...)
+	if err != nil {
+		return err
+	}
+
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	return client.connection.WriteMessage(websocket.TextMessage, data)
+}
+
 func (client *webSocketClient) Disconnect() error {
 	message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
 
@@ -3,4 +3,5 @@ package analytics
 type IMeasurementsProvider interface {
 	SubmitMeasurement(string, Tags, Fields)
 	SubmitMeasurementAsync(string, Tags, Fields)
+	Flush()
 }
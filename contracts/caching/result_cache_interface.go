@@ -0,0 +1,17 @@
+package caching
+
+import (
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/operation"
+)
+
+type IResultCache interface {
+	Put(string, IOperationResult, time.Duration, ...string)
+	Get(string) (IOperationResult, bool)
+	Remove(string)
+	Invalidate(...string)
+	Clear()
+	Size() int
+	Capacity() int
+}
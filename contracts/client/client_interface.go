@@ -1,11 +1,21 @@
 package client
 
 import (
+	"context"
+	"crypto/tls"
+	"time"
+
 	. "github.com/xeronith/diamante/contracts/operation"
 	. "github.com/xeronith/diamante/contracts/serialization"
 	. "github.com/xeronith/diamante/contracts/system"
 )
 
+type BatchItem struct {
+	Id        uint64
+	Operation uint64
+	Payload   Pointer
+}
+
 type IClient interface {
 	SetName(string)
 	SetToken(string)
@@ -14,8 +24,40 @@ type IClient interface {
 	Connect(string, string) error
 	Disconnect() error
 	Send(uint64, uint64, Pointer) error
+	SendBatch(uint64, bool, ...BatchItem) error
 	OnConnectionEstablished(func(IClient))
 	SetOperationResultListener(func(IOperationResult))
+	SetStreamListener(func(IOperationResult))
 	Serializer() ISerializer
 	IsActive() bool
 }
+
+type IFuture interface {
+	Id() uint64
+	Done() <-chan struct{}
+	Result() (IOperationResult, error)
+	Wait(context.Context) (IOperationResult, error)
+}
+
+// IRequester sends requests with ids of its own and returns the futures of
+// their results. RequestVersion asks for the given api version of the
+// operation rather than the one set on the client.
+type IRequester interface {
+	Request(uint64, Pointer) IFuture
+	RequestVersion(uint64, int32, Pointer) IFuture
+}
+
+type IRequestingClient interface {
+	IClient
+	IRequester
+}
+
+type IReconnectingClient interface {
+	IClient
+	IRequester
+	SetTLSConfig(*tls.Config)
+	SetBackoff(time.Duration, time.Duration)
+	SetRequestTimeout(time.Duration)
+	SetOfflineBufferSize(int)
+	IsConnected() bool
+}
@@ -1,5 +1,7 @@
 package database
 
+import "context"
+
 type (
 	Iterator              func(ICursor) error
 	Parameter             = interface{}
@@ -32,6 +34,14 @@ type (
 		OnChanged(func(...string))
 	}
 
+	// IContextualSqlDatabase is implemented by the databases whose commands
+	// can be bound to a context, so that they are cancelled along with it.
+	// It is optional, callers type-assert for it.
+	IContextualSqlDatabase interface {
+		ISqlDatabase
+		WithContext(context.Context) ISqlDatabase
+	}
+
 	ISqlTransaction interface {
 		OnCommit(func())
 	}
@@ -1,6 +1,8 @@
 package io
 
 import (
+	"context"
+
 	. "github.com/xeronith/diamante/contracts/operation"
 	. "github.com/xeronith/diamante/contracts/serialization"
 )
@@ -21,3 +23,19 @@ type IWriter interface {
 	Serializer() ISerializer
 	Close()
 }
+
+type IContextWriter interface {
+	IWriter
+	Context() context.Context
+}
+
+type ITransportWriter interface {
+	IWriter
+	Transport() string
+}
+
+type IResumableWriter interface {
+	IWriter
+	Attach(IWriter) bool
+	Detach(IWriter) bool
+}
@@ -0,0 +1,7 @@
+package messaging
+
+type IMessageBus interface {
+	Publish([]byte) error
+	Subscribe(func([]byte))
+	Close() error
+}
@@ -27,6 +27,36 @@ type (
 		IsCacheable() bool
 	}
 
+	ISequentialOperation interface {
+		IOperation
+		IsSequential() bool
+	}
+
+	// ICancellableOperation is implemented by the operations that are
+	// cancelled, and answered with a gateway timeout, once they run past
+	// their critical time limit. Others are only logged when they do.
+	ICancellableOperation interface {
+		IOperation
+		IsCancellable() bool
+	}
+
+	ICacheableOperation interface {
+		IOperation
+		CacheTTL() Duration
+		CacheTags() []string
+	}
+
+	IStreamingOperation interface {
+		IOperation
+		ExecuteStream(IStreamContext, Pointer) error
+	}
+
+	IVersionedOperation interface {
+		IOperation
+		ApiVersions() (int32, int32)
+		Deprecation() (bool, Time)
+	}
+
 	IOperationFactory interface {
 		Operations() []IOperation
 	}
@@ -16,8 +16,12 @@ type IOperationResult interface {
 	ServerVersion() int32
 	ExecutionDuration() time.Duration
 	UpdateStat(bool, int64, int64) IOperationResult
+	IsCached() bool
 	Signature() string
 	Payload() []byte
 	Load(interface{}, ISerializer) error
 	Stat() (int64, int64)
+	IsPartial() bool
+	Deprecation() (bool, time.Time)
+	ClientLatestVersion() int32
 }
@@ -0,0 +1,25 @@
+package presence
+
+type (
+	Connection struct {
+		Token         string
+		RemoteAddress string
+		UserAgent     string
+		LastActivity  int64
+	}
+
+	Event struct {
+		IdentityId int64
+		Online     bool
+		Connection Connection
+	}
+
+	IPresence interface {
+		IsOnline(int64) bool
+		Connections(int64) []Connection
+		LastActivity(int64) int64
+		OnlineIdentities() []int64
+		Subscribe(func(Event)) string
+		Unsubscribe(string)
+	}
+)
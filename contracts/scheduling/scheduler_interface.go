@@ -4,6 +4,7 @@ import "time"
 
 type IScheduler interface {
 	Start()
+	Stop()
 	SetTimeout(func(), time.Duration) string
 	SetInterval(func(), time.Duration) string
 	Cancel(string)
@@ -9,7 +9,12 @@ import (
 	. "github.com/xeronith/diamante/contracts/system"
 )
 
-const SYSTEM_CALL_REQUEST = 0x00001000
+const (
+	SYSTEM_CALL_REQUEST = 0x00001000
+	BATCH_REQUEST       = 0x00000010
+	BATCH_RESULT        = 0x00000011
+	RESUMPTION_RESULT   = 0x00000012
+)
 
 type Opcodes map[uint64]string
 
@@ -19,9 +24,15 @@ type IBaseServer interface {
 	ActorsCount() int
 	IncrementActorsCount(IActor)
 	Scheduler() IScheduler
+	SetScheduler(IScheduler)
 	Logger() ILogger
+	SetLogger(ILogger)
 	Localizer() ILocalizer
 	Push(IActor, messaging.IPushMessage) error
+	PushToken(string, messaging.IPushMessage) error
 	Broadcast(uint64, Pointer) error
 	BroadcastSpecific(uint64, map[string]Pointer) error
+	Subscribe(IActor, string) error
+	Unsubscribe(IActor, string) error
+	Publish(string, uint64, Pointer) error
 }
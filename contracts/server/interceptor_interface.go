@@ -0,0 +1,21 @@
+package server
+
+import (
+	. "github.com/xeronith/diamante/contracts/operation"
+)
+
+type (
+	InterceptorBeforeFunc func(IPipeline, IOperationRequest) IOperationResult
+	InterceptorAfterFunc  func(IPipeline, IOperationRequest, IOperationResult) IOperationResult
+
+	// IInterceptor wraps every operation request that reaches OnOperationRequest,
+	// batch envelopes included: their pipeline has no operation, and each of
+	// their requests goes through the chain again on its own. Before is called
+	// in registration order; returning a non-nil result short-circuits the rest
+	// of the chain and the operation itself. After is called in reverse order
+	// with the produced result and may replace it by returning a non-nil value.
+	IInterceptor interface {
+		Before(IPipeline, IOperationRequest) IOperationResult
+		After(IPipeline, IOperationRequest, IOperationResult) IOperationResult
+	}
+)
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"time"
+
 	. "github.com/xeronith/diamante/contracts/actor"
 	. "github.com/xeronith/diamante/contracts/operation"
 	. "github.com/xeronith/diamante/contracts/serialization"
@@ -15,6 +17,7 @@ type IPipeline interface {
 	ResultType() uint64
 	ContentType() string
 	ApiVersion() int32
+	Deprecation() (bool, time.Time)
 	ServerVersion() int32
 	ClientVersion() int32
 	ClientLatestVersion() int32
@@ -22,6 +25,7 @@ type IPipeline interface {
 	IsFrozen() bool
 	IsSystemCall() bool
 	Sign([]byte) string
+	RequestHash() string
 	Signature() string
 	IsAcceptable(IOperationResult) bool
 
@@ -30,4 +34,8 @@ type IPipeline interface {
 	NotImplemented(...error) IOperationResult
 	Unauthorized(...error) IOperationResult
 	BadRequest(...error) IOperationResult
+	Gone(...error) IOperationResult
+	UpgradeRequired(...error) IOperationResult
+	GatewayTimeout(...error) IOperationResult
+	TooManyRequests(time.Duration, ...error) IOperationResult
 }
@@ -0,0 +1,22 @@
+package server
+
+import (
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/security"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type IOperationDescriptor interface {
+	Opcode() uint64
+	ResultId() uint64
+	Tag() string
+	ApiVersions() (int32, int32)
+	Deprecation() (bool, time.Time)
+	Role() Role
+	IsCacheable() bool
+	IsSequential() bool
+	IsStreaming() bool
+	Input() protoreflect.MessageDescriptor
+	Output() protoreflect.MessageDescriptor
+}
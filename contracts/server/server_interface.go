@@ -1,24 +1,31 @@
 package server
 
 import (
+	"context"
+
 	. "github.com/xeronith/diamante/contracts/actor"
 	. "github.com/xeronith/diamante/contracts/analytics"
+	. "github.com/xeronith/diamante/contracts/caching"
 	. "github.com/xeronith/diamante/contracts/email"
 	. "github.com/xeronith/diamante/contracts/io"
+	. "github.com/xeronith/diamante/contracts/messaging"
 	. "github.com/xeronith/diamante/contracts/network/http"
 	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/presence"
 	. "github.com/xeronith/diamante/contracts/security"
 	. "github.com/xeronith/diamante/contracts/serialization"
 	. "github.com/xeronith/diamante/contracts/settings"
 	. "github.com/xeronith/diamante/contracts/sms"
 	. "github.com/xeronith/diamante/contracts/system"
+	. "github.com/xeronith/diamante/contracts/throttling"
 )
 
 type IServer interface {
 	IBaseServer
 
 	Start()
-	Shutdown()
+	Shutdown(context.Context) error
+	IsShuttingDown() bool
 
 	OnServerStarted(func())
 	OnActorConnected(func(string))
@@ -26,13 +33,23 @@ type IServer interface {
 
 	SetSecurityHandler(ISecurityHandler)
 
+	MessageBus() IMessageBus
+	SetMessageBus(IMessageBus)
+
+	Cache() IResultCache
+	RateLimiter() IRateLimiter
+	SetRateLimiter(IRateLimiter)
+
 	Version() int32
 	RegisterClientVersion(string, int32)
 	ResolveClientVersion(string) int32
+	SetClientMinimumVersion(string, int32)
+	ResolveClientMinimumVersion(string) int32
 	Configuration() IConfiguration
 
 	ActiveEndpoint() string
 	PassiveEndpoint() string
+	GRPCEndpoint() string
 
 	OnStorageUpdated() func(...string)
 
@@ -48,6 +65,7 @@ type IServer interface {
 	SMSProvider() ISMSProvider
 	SetSMSProvider(ISMSProvider)
 
+	Presence() IPresence
 	Actor(string) (IActor, error)
 	Session(string) (ISystemObject, error)
 	SetSession(string, ISystemObject) error
@@ -60,10 +78,19 @@ type IServer interface {
 
 	RegisterOperation(IOperation) error
 	RegisterOperations(...IOperation) error
+	OperationDescriptors() []IOperationDescriptor
+	OperationDescriptor(uint64, int32) (IOperationDescriptor, bool)
+	Schema() ([]byte, error)
 
 	RegisterHttpHandler(IHttpHandler) error
 	RegisterHttpHandlers(...IHttpHandler) error
 
+	RegisterTransport(ITransport) error
+	RegisterTransports(...ITransport) error
+
+	RegisterInterceptor(IInterceptor) error
+	RegisterInterceptors(...IInterceptor) error
+
 	SetAsciiArt(string)
 	SetHUDEnabled(bool)
 }
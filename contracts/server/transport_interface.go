@@ -0,0 +1,7 @@
+package server
+
+type ITransport interface {
+	Name() string
+	Listen(IServer) error
+	Close() error
+}
@@ -1,11 +1,15 @@
 package service
 
 import (
+	"context"
+	"io"
+	"net/http"
 	"time"
 
 	"github.com/xeronith/diamante/contracts/analytics"
 	. "github.com/xeronith/diamante/contracts/logging"
 	. "github.com/xeronith/diamante/contracts/messaging"
+	. "github.com/xeronith/diamante/contracts/presence"
 	. "github.com/xeronith/diamante/contracts/scheduling"
 	. "github.com/xeronith/diamante/contracts/security"
 	. "github.com/xeronith/diamante/contracts/settings"
@@ -13,6 +17,8 @@ import (
 )
 
 type IContext interface {
+	Context() context.Context
+	NewHttpRequest(string, string, io.Reader) (*http.Request, error)
 	Configuration() IConfiguration
 	SetSecureCookie(string, string)
 	GetSecureCookie(string) string
@@ -30,6 +36,7 @@ type IContext interface {
 	SetResultType(ID)
 	IncrementActorsCount()
 	ActorsCount() int
+	Presence() IPresence
 	Scheduler() IScheduler
 	SetTimeout(func(), time.Duration) string
 	SetInterval(func(), time.Duration) string
@@ -39,6 +46,9 @@ type IContext interface {
 	Push(IPushMessage) error
 	Broadcast(uint64, Pointer) error
 	BroadcastSpecific(uint64, map[string]Pointer) error
+	Subscribe(string) error
+	Unsubscribe(string) error
+	Publish(string, uint64, Pointer) error
 	SMS(string, string) error
 	Timestamp() time.Time
 	IsStagingEnvironment() bool
@@ -51,3 +61,9 @@ type IContext interface {
 	Unlock()
 	SystemCall([]string) error
 }
+
+type IStreamContext interface {
+	IContext
+	Emit(Pointer) error
+	Complete()
+}
@@ -1,5 +1,7 @@
 package settings
 
+import "time"
+
 type (
 	IConfiguration interface {
 		IsDockerized() bool
@@ -23,6 +25,14 @@ type (
 		GetProtocol() string
 		GetPortConfiguration() IPortConfiguration
 		GetTLSConfiguration() ITLSConfiguration
+		GetWebSocketConfiguration() IWebSocketConfiguration
+		GetCacheConfiguration() ICacheConfiguration
+		GetClusterConfiguration() IClusterConfiguration
+		GetTrafficConfiguration() ITrafficConfiguration
+		GetRequestLogConfiguration() IRequestLogConfiguration
+		GetBatchConfiguration() IBatchConfiguration
+		GetStreamingConfiguration() IStreamingConfiguration
+		GetClientsConfiguration() IClientsConfiguration
 		GetBuildNumber() int32
 		SetBuildNumber(int32)
 		GetJwtTokenKey() string
@@ -35,6 +45,7 @@ type (
 		GetActive() int
 		GetPassive() int
 		GetDiagnostics() int
+		GetGRPC() int
 	}
 
 	ITLSConfiguration interface {
@@ -43,6 +54,59 @@ type (
 		GetCertFile() string
 	}
 
+	IWebSocketConfiguration interface {
+		GetWorkers() int
+		GetHeartbeatInterval() time.Duration
+		GetIdleTimeout() time.Duration
+		GetReadBufferSize() int
+		GetWriteBufferSize() int
+		GetMaxMessageSize() int64
+		IsCompressionEnabled() bool
+		GetCompressionLevel() int
+		GetCompressionThreshold() int
+		GetResumptionGracePeriod() time.Duration
+		GetResumptionQueueSize() int
+	}
+
+	ICacheConfiguration interface {
+		GetCapacity() int
+		GetTTL() time.Duration
+	}
+
+	IClusterConfiguration interface {
+		IsEnabled() bool
+		GetAddress() string
+		GetSecret() string
+		GetPeers() []string
+	}
+
+	ITrafficConfiguration interface {
+		GetPath() string
+		GetMaxFileSize() int64
+		GetMaxFiles() int
+	}
+
+	IRequestLogConfiguration interface {
+		GetFormat() string
+		GetSampleRate() float64
+		GetRedactedFields() []string
+		GetPath() string
+	}
+
+	IBatchConfiguration interface {
+		GetMaxSize() int
+		GetWorkers() int
+	}
+
+	IStreamingConfiguration interface {
+		GetTimeout() time.Duration
+	}
+
+	IClientsConfiguration interface {
+		IsAnonymousRejected() bool
+		GetDefaultApiVersion() int32
+	}
+
 	IPostgreSQLConfiguration interface {
 		GetHost() string
 		SetHost(string)
@@ -0,0 +1,36 @@
+package throttling
+
+import (
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/security"
+)
+
+// noinspection GoSnakeCaseUsage
+const (
+	ANY_OPCODE uint64 = 0
+	ANY_ROLE   Role   = 0xFFFF_FFFF_FFFF_FFFF
+)
+
+type (
+	IRateLimit interface {
+		Rate() float64
+		Burst() int
+	}
+
+	// IRateLimitStore keeps token buckets. Take consumes one token from the
+	// bucket identified by key and reports how long the caller should wait
+	// before retrying when the bucket is empty.
+	IRateLimitStore interface {
+		Take(key string, rate float64, burst int) (bool, time.Duration)
+		Reset(key string)
+	}
+
+	IRateLimiter interface {
+		SetLimit(uint64, Role, float64, int)
+		RemoveLimit(uint64, Role)
+		Limit(uint64, Role) (IRateLimit, bool)
+		Allow(uint64, Role, string) (bool, time.Duration)
+		Store() IRateLimitStore
+	}
+)
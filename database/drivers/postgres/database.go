@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -21,6 +22,7 @@ type sqlDatabase struct {
 	name             string
 	connectionString string
 	callbacks        ISlice
+	context          context.Context
 }
 
 func NewDatabase(configuration IConfiguration, logger ILogger, dbname string) ISqlDatabase {
@@ -43,9 +45,31 @@ func NewDatabase(configuration IConfiguration, logger ILogger, dbname string) IS
 		name:             dbname,
 		connectionString: fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", host, port, user, password, dbname),
 		callbacks:        NewConcurrentSlice(),
+		context:          context.Background(),
 	}
 }
 
+func (database *sqlDatabase) WithContext(ctx context.Context) ISqlDatabase {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return &sqlDatabase{
+		name:             database.name,
+		connectionString: database.connectionString,
+		callbacks:        database.callbacks,
+		context:          ctx,
+	}
+}
+
+func (database *sqlDatabase) getContext() context.Context {
+	if database.context == nil {
+		return context.Background()
+	}
+
+	return database.context
+}
+
 func (database *sqlDatabase) GetName() string {
 	return database.name
 }
@@ -59,7 +83,7 @@ func (database *sqlDatabase) Initialize() error {
 
 	defer func() { _ = db.Close() }()
 
-	result, err := db.Query(command)
+	result, err := db.QueryContext(database.getContext(), command)
 	if err != nil {
 		return err
 	}
@@ -113,14 +137,14 @@ func (database *sqlDatabase) RunScript(script string, separator string) error {
 
 	defer func() { _ = db.Close() }()
 
-	tx, err := db.Begin()
+	tx, err := db.BeginTx(database.getContext(), nil)
 	if err != nil {
 		return err
 	}
 
 	for _, statement := range strings.Split(script, separator) {
 		if strings.TrimSpace(statement) != "" {
-			if _, err := tx.Exec(statement); err != nil {
+			if _, err := tx.ExecContext(database.getContext(), statement); err != nil {
 				if err := tx.Rollback(); err != nil {
 					return err
 				}
@@ -145,7 +169,7 @@ func (database *sqlDatabase) Query(iterator Iterator, command Command, parameter
 
 	defer func() { _ = db.Close() }()
 
-	result, err := db.Query(command, parameters...)
+	result, err := db.QueryContext(database.getContext(), command, parameters...)
 	if err != nil {
 		return err
 	}
@@ -171,7 +195,7 @@ func (database *sqlDatabase) QuerySingle(iterator Iterator, command Command, par
 
 	defer func() { _ = db.Close() }()
 
-	result, err := db.Query(command, parameters...)
+	result, err := db.QueryContext(database.getContext(), command, parameters...)
 	if err != nil {
 		return err
 	}
@@ -199,7 +223,7 @@ func (database *sqlDatabase) Execute(command Command, parameters ...Parameter) (
 
 	defer func() { _ = db.Close() }()
 
-	result, err := db.Exec(command, parameters...)
+	result, err := db.ExecContext(database.getContext(), command, parameters...)
 	if err != nil {
 		return 0, err
 	}
@@ -218,7 +242,7 @@ func (database *sqlDatabase) Execute(command Command, parameters ...Parameter) (
 
 func (database *sqlDatabase) ExecuteAtomic(transaction ISqlTransaction, command Command, parameters ...Parameter) (int64, error) {
 	if sqlTransaction, ok := transaction.(*sqlTransaction); ok {
-		result, err := sqlTransaction.databaseTransaction.Exec(command, parameters...)
+		result, err := sqlTransaction.databaseTransaction.ExecContext(database.getContext(), command, parameters...)
 		if err != nil {
 			return 0, err
 		}
@@ -255,12 +279,12 @@ func (database *sqlDatabase) ExecuteBatch(command Command, count int64, paramete
 
 	defer func() { _ = db.Close() }()
 
-	transaction, err := db.Begin()
+	transaction, err := db.BeginTx(database.getContext(), nil)
 	if err != nil {
 		return 0, err
 	}
 
-	statement, err := transaction.Prepare(command)
+	statement, err := transaction.PrepareContext(database.getContext(), command)
 	if err != nil {
 		return 0, err
 	}
@@ -271,7 +295,7 @@ func (database *sqlDatabase) ExecuteBatch(command Command, count int64, paramete
 	total := int64(0)
 	for i := int64(0); i < count; i++ {
 		offset := parametersCount * i
-		result, err := statement.Exec(parameters[offset : offset+parametersCount]...)
+		result, err := statement.ExecContext(database.getContext(), parameters[offset:offset+parametersCount]...)
 		if err != nil {
 			lastError = err
 			break
@@ -385,7 +409,7 @@ func (database *sqlDatabase) Count(command Command, parameters ...Parameter) (in
 	defer func() { _ = db.Close() }()
 
 	count := 0
-	if err := db.QueryRow(command, parameters...).Scan(&count); err != nil {
+	if err := db.QueryRowContext(database.getContext(), command, parameters...).Scan(&count); err != nil {
 		return 0, err
 	}
 
@@ -400,7 +424,7 @@ func (database *sqlDatabase) WithTransaction(handler SqlTransactionHandler) (err
 
 	defer func() { _ = db.Close() }()
 
-	tx, err := db.Begin()
+	tx, err := db.BeginTx(database.getContext(), nil)
 	if err != nil {
 		return err
 	}
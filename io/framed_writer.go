@@ -0,0 +1,144 @@
+package io
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/io"
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/serialization"
+	. "github.com/xeronith/diamante/contracts/server"
+)
+
+type framedWriter struct {
+	sync.RWMutex
+	base       baseWriter
+	connection net.Conn
+}
+
+// CreateFramedWriter creates a writer that sends every result over the
+// connection as a frame prefixed with its length as a 4-byte big-endian integer.
+func CreateFramedWriter(server IServer, connection net.Conn, onClosed func()) IWriter {
+	return &framedWriter{
+		base:       createBaseWriter(server, onClosed, "application/octet-stream"),
+		connection: connection,
+	}
+}
+
+func (writer *framedWriter) ContentType() string {
+	return writer.base.contentType
+}
+
+func (writer *framedWriter) Transport() string {
+	return "tcp"
+}
+
+func (writer *framedWriter) IsClosed() bool {
+	return !writer.IsOpen()
+}
+
+func (writer *framedWriter) IsOpen() bool {
+	writer.RLock()
+	defer writer.RUnlock()
+
+	return !writer.base.closed
+}
+
+func (writer *framedWriter) SetSecureCookie(_, _ string) {
+	writer.base.logger.Error("FRAMED WRITER: SetSecureCookie not supported")
+}
+
+func (writer *framedWriter) GetSecureCookie(_ string) string {
+	writer.base.logger.Error("FRAMED WRITER: GetSecureCookie not supported")
+	return ""
+}
+
+func (writer *framedWriter) SetAuthCookie(_ string) {
+	writer.base.logger.Error("FRAMED WRITER: SetAuthCookie not supported")
+}
+
+func (writer *framedWriter) GetAuthCookie() string {
+	return ""
+}
+
+func (writer *framedWriter) SetToken(token string) {
+	writer.base.token = token
+}
+
+func (writer *framedWriter) Write(result IOperationResult) {
+	if data, err := writer.base.serializer.Serialize(result.Container()); err != nil {
+		writer.base.logger.Error(fmt.Sprintf("FRAMED/OR SERIALIZATION ERROR {%s}: %s", writer.base.token, err))
+	} else {
+		writer.WriteBytes(data)
+	}
+}
+
+func (writer *framedWriter) WriteBytes(data []byte) {
+	frame := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	copy(frame[4:], data)
+
+	var closed bool
+	func() {
+		writer.Lock()
+		defer writer.Unlock()
+
+		if writer.base.closed {
+			writer.base.logger.Warning("FRAMED WRITE ERROR: writer closed")
+			return
+		}
+
+		if err := writer.connection.SetWriteDeadline(time.Now().Add(time.Second * 4)); err != nil {
+			writer.base.logger.Error(fmt.Sprintf("FRAMED/SWD ERROR {%s}: %s", writer.base.token, err))
+			return
+		}
+
+		if _, err := writer.connection.Write(frame); err != nil {
+			closed = true
+			writer.base.closed = true
+			writer.base.logger.Error(fmt.Sprintf("FRAMED/OR WRITE ERROR {%s}: %s", writer.base.token, err))
+		}
+	}()
+
+	if closed {
+		writer.finalize()
+	}
+}
+
+func (writer *framedWriter) WriteByte(code byte) error {
+	writer.WriteBytes([]byte{code})
+	return nil
+}
+
+func (writer *framedWriter) End(result IOperationResult) {
+	writer.Write(result)
+	writer.Close()
+}
+
+func (writer *framedWriter) Serializer() ISerializer {
+	return writer.base.serializer
+}
+
+func (writer *framedWriter) Close() {
+	writer.Lock()
+	if writer.base.closed {
+		writer.Unlock()
+		return
+	}
+
+	writer.base.closed = true
+	writer.Unlock()
+
+	writer.finalize()
+}
+
+func (writer *framedWriter) finalize() {
+	_ = writer.connection.Close()
+
+	if writer.base.onClosed != nil {
+		writer.base.onClosed()
+	}
+}
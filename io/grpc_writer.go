@@ -0,0 +1,130 @@
+package io
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	. "github.com/xeronith/diamante/contracts/io"
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/serialization"
+	. "github.com/xeronith/diamante/contracts/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type grpcWriter struct {
+	sync.RWMutex
+	base    baseWriter
+	context context.Context
+	stream  grpc.ServerStream
+}
+
+// CreateGRPCWriter creates the writer of a single grpc call. The final result
+// is returned by the call handler, so the writer only sends the partial results
+// of server streaming calls, which is when stream is not nil.
+func CreateGRPCWriter(server IServer, context context.Context, stream grpc.ServerStream) IContextWriter {
+	return &grpcWriter{
+		base:    createBaseWriter(server, nil, "application/octet-stream"),
+		context: context,
+		stream:  stream,
+	}
+}
+
+func (writer *grpcWriter) Context() context.Context {
+	return writer.context
+}
+
+func (writer *grpcWriter) ContentType() string {
+	return writer.base.contentType
+}
+
+func (writer *grpcWriter) Transport() string {
+	return "grpc"
+}
+
+func (writer *grpcWriter) IsClosed() bool {
+	return !writer.IsOpen()
+}
+
+func (writer *grpcWriter) IsOpen() bool {
+	writer.RLock()
+	defer writer.RUnlock()
+
+	return !writer.base.closed
+}
+
+func (writer *grpcWriter) SetSecureCookie(_, _ string) {
+	writer.base.logger.Error("GRPC WRITER: SetSecureCookie not supported")
+}
+
+func (writer *grpcWriter) GetSecureCookie(_ string) string {
+	writer.base.logger.Error("GRPC WRITER: GetSecureCookie not supported")
+	return ""
+}
+
+func (writer *grpcWriter) SetAuthCookie(token string) {
+	if err := grpc.SetHeader(writer.context, metadata.Pairs("x-token", token)); err != nil {
+		writer.base.logger.Error(fmt.Sprintf("GRPC/TKN WRITE ERROR: %s", err))
+	}
+}
+
+func (writer *grpcWriter) GetAuthCookie() string {
+	return ""
+}
+
+func (writer *grpcWriter) SetToken(token string) {
+	writer.base.token = token
+}
+
+func (writer *grpcWriter) Write(result IOperationResult) {
+	if !result.IsPartial() || writer.stream == nil {
+		return
+	}
+
+	writer.Lock()
+	defer writer.Unlock()
+
+	if writer.base.closed {
+		writer.base.logger.Warning("GRPC WRITE ERROR: writer closed")
+		return
+	}
+
+	payload := result.Payload()
+	if err := writer.stream.SendMsg(&payload); err != nil {
+		writer.base.closed = true
+		writer.base.logger.Error(fmt.Sprintf("GRPC/OR WRITE ERROR {%s}: %s", writer.base.token, err))
+	}
+}
+
+func (writer *grpcWriter) WriteByte(_ byte) error {
+	writer.base.logger.Error("GRPC WRITER: WriteByte not supported")
+	return nil
+}
+
+func (writer *grpcWriter) WriteBytes(_ []byte) {
+	writer.base.logger.Error("GRPC WRITER: WriteBytes not supported")
+}
+
+func (writer *grpcWriter) End(result IOperationResult) {
+	writer.Write(result)
+	writer.Close()
+}
+
+func (writer *grpcWriter) Serializer() ISerializer {
+	return writer.base.serializer
+}
+
+func (writer *grpcWriter) Close() {
+	writer.Lock()
+	defer writer.Unlock()
+
+	if writer.base.closed {
+		return
+	}
+
+	writer.base.closed = true
+	if writer.base.onClosed != nil {
+		writer.base.onClosed()
+	}
+}
@@ -1,6 +1,7 @@
 package io
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"math/rand"
@@ -15,6 +16,7 @@ import (
 	. "github.com/xeronith/diamante/contracts/operation"
 	. "github.com/xeronith/diamante/contracts/serialization"
 	. "github.com/xeronith/diamante/contracts/server"
+	. "github.com/xeronith/diamante/protobuf"
 
 	_ "embed"
 )
@@ -36,6 +38,7 @@ type httpWriter struct {
 	timestamp    time.Time
 	opcodes      Opcodes
 	secureCookie *securecookie.SecureCookie
+	streaming    bool
 }
 
 func CreateHttpWriter(
@@ -59,6 +62,10 @@ func (writer *httpWriter) ContentType() string {
 	return writer.base.contentType
 }
 
+func (writer *httpWriter) Transport() string {
+	return "http"
+}
+
 func (writer *httpWriter) IsClosed() bool {
 	return !writer.IsOpen()
 }
@@ -120,6 +127,11 @@ func (writer *httpWriter) Write(result IOperationResult) {
 		return
 	}
 
+	if result.IsPartial() || writer.streaming {
+		writer.writeEvent(result)
+		return
+	}
+
 	serviceDuration := float64(result.ExecutionDuration().Microseconds()) / 1000
 	pipelineDuration := float64(time.Since(writer.timestamp).Microseconds()) / 1000
 
@@ -138,6 +150,24 @@ func (writer *httpWriter) Write(result IOperationResult) {
 		writer.context.Response().Header().Add("X-Turbo", "On")
 	}
 
+	if latestVersion := result.ClientLatestVersion(); latestVersion > 0 {
+		writer.context.Response().Header().Set("X-Update-Available", fmt.Sprintf("%d", latestVersion))
+	}
+
+	if deprecated, sunset := result.Deprecation(); deprecated {
+		writer.context.Response().Header().Set("Deprecation", "true")
+		if !sunset.IsZero() {
+			writer.context.Response().Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+	}
+
+	if result.Status() == http.StatusTooManyRequests {
+		serverError := &ServerError{}
+		if err := writer.base.serializer.Deserialize(result.Payload(), serverError); err == nil && serverError.RetryAfter > 0 {
+			writer.context.Response().Header().Set("Retry-After", fmt.Sprintf("%d", (serverError.RetryAfter+999)/1000))
+		}
+	}
+
 	quote := (*quotes)[rand.Intn(len(*quotes))]
 	writer.context.Response().Header().Add("X-Quote", fmt.Sprintf("%s: %s", quote.Author, quote.Quote))
 
@@ -147,16 +177,8 @@ func (writer *httpWriter) Write(result IOperationResult) {
 		return
 	}
 
-	data, err := writer.base.serializer.Serialize(result.Container())
+	data, err := writer.serialize(result)
 	if err == nil {
-		if result.ContentType() == "application/json" {
-			var response, responsePayload map[string]interface{}
-			_ = json.Unmarshal(data, &response)
-			_ = json.Unmarshal(result.Payload(), &responsePayload)
-			response["payload"] = responsePayload
-			data, _ = json.Marshal(response)
-		}
-
 		if err := writer.context.Blob(int(result.Status()), result.ContentType(), data); err != nil {
 			writer.base.logger.Error(fmt.Sprintf("HTTP/OR WRITE ERROR: %s", err))
 		}
@@ -168,6 +190,61 @@ func (writer *httpWriter) Write(result IOperationResult) {
 	}
 }
 
+func (writer *httpWriter) serialize(result IOperationResult) ([]byte, error) {
+	data, err := writer.base.serializer.Serialize(result.Container())
+	if err != nil {
+		return nil, err
+	}
+
+	if result.ContentType() == "application/json" {
+		var response, responsePayload map[string]interface{}
+		_ = json.Unmarshal(data, &response)
+		_ = json.Unmarshal(result.Payload(), &responsePayload)
+		response["payload"] = responsePayload
+		data, _ = json.Marshal(response)
+	}
+
+	return data, nil
+}
+
+// writeEvent sends the results of a streaming operation as server-sent events.
+// Partial results are sent as 'partial' events and the final result that
+// terminates the stream as an 'end' event. Binary content is base64 encoded.
+func (writer *httpWriter) writeEvent(result IOperationResult) {
+	response := writer.context.Response()
+	if !writer.streaming {
+		writer.streaming = true
+		response.Header().Set(echo.HeaderContentType, "text/event-stream")
+		response.Header().Set(echo.HeaderCacheControl, "no-cache")
+		response.Header().Set("X-Accel-Buffering", "no")
+		response.Header().Set("X-Request-Timestamp", fmt.Sprintf("%d", result.Id()))
+		response.WriteHeader(http.StatusOK)
+	}
+
+	data, err := writer.serialize(result)
+	if err != nil {
+		writer.base.logger.Error(fmt.Sprintf("HTTP/SSE SERIALIZATION ERROR: %s", err))
+		return
+	}
+
+	event := "end"
+	if result.IsPartial() {
+		event = "partial"
+	}
+
+	encoded := string(data)
+	if result.ContentType() != "application/json" {
+		encoded = base64.StdEncoding.EncodeToString(data)
+	}
+
+	if _, err := fmt.Fprintf(response, "event: %s\ndata: %s\n\n", event, encoded); err != nil {
+		writer.base.logger.Error(fmt.Sprintf("HTTP/SSE WRITE ERROR: %s", err))
+		return
+	}
+
+	response.Flush()
+}
+
 func (writer *httpWriter) WriteByte(_ byte) error {
 	writer.base.logger.Error("HTTP WRITER: WriteByte not supported")
 	return nil
@@ -0,0 +1,269 @@
+package io
+
+import (
+	"fmt"
+	"sync"
+
+	. "github.com/xeronith/diamante/contracts/io"
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/serialization"
+	. "github.com/xeronith/diamante/contracts/server"
+)
+
+type resumableWriter struct {
+	sync.RWMutex
+	base      baseWriter
+	writing   sync.Mutex
+	writer    IWriter
+	queue     [][]byte
+	queueSize int
+}
+
+// CreateResumableWriter creates a writer that outlives the connections of an
+// actor. Messages are forwarded to the attached writer and, while no writer is
+// attached, up to queueSize of them are kept to be replayed in order by the
+// next Attach. The writer closes itself when the queue overflows.
+func CreateResumableWriter(server IServer, writer IWriter, queueSize int, onClosed func()) IResumableWriter {
+	return &resumableWriter{
+		base:      createBaseWriter(server, onClosed, "application/octet-stream"),
+		writer:    writer,
+		queue:     make([][]byte, 0),
+		queueSize: queueSize,
+	}
+}
+
+// Attach replaces the current writer, if any, and replays the queued messages
+// through the new one. It reports false when this writer is already closed.
+func (writer *resumableWriter) Attach(target IWriter) bool {
+	writer.writing.Lock()
+	defer writer.writing.Unlock()
+
+	writer.Lock()
+	if writer.base.closed {
+		writer.Unlock()
+		return false
+	}
+
+	previous, queue := writer.writer, writer.queue
+	writer.writer, writer.queue = target, make([][]byte, 0)
+	target.SetToken(writer.base.token)
+	writer.Unlock()
+
+	if previous != nil && previous != target {
+		previous.Close()
+	}
+
+	for index, data := range queue {
+		if target.IsClosed() {
+			writer.Lock()
+			writer.queue = append(queue[index:], writer.queue...)
+			writer.Unlock()
+			break
+		}
+
+		target.WriteBytes(data)
+	}
+
+	return true
+}
+
+// Detach removes the writer so the following messages are queued. It reports
+// false when the writer is not the attached one, either because another
+// connection has taken over or because this writer is closed.
+func (writer *resumableWriter) Detach(target IWriter) bool {
+	writer.Lock()
+	defer writer.Unlock()
+
+	if writer.base.closed || writer.writer != target {
+		return false
+	}
+
+	writer.writer = nil
+	return true
+}
+
+func (writer *resumableWriter) ContentType() string {
+	return writer.base.contentType
+}
+
+// Transport returns the transport of the attached writer.
+func (writer *resumableWriter) Transport() string {
+	writer.RLock()
+	defer writer.RUnlock()
+
+	if transportWriter, ok := writer.writer.(ITransportWriter); ok {
+		return transportWriter.Transport()
+	}
+
+	return ""
+}
+
+func (writer *resumableWriter) IsClosed() bool {
+	return !writer.IsOpen()
+}
+
+func (writer *resumableWriter) IsOpen() bool {
+	writer.RLock()
+	defer writer.RUnlock()
+
+	return !writer.base.closed
+}
+
+func (writer *resumableWriter) SetSecureCookie(name, value string) {
+	if current := writer.current(); current != nil {
+		current.SetSecureCookie(name, value)
+	}
+}
+
+func (writer *resumableWriter) GetSecureCookie(name string) string {
+	if current := writer.current(); current != nil {
+		return current.GetSecureCookie(name)
+	}
+
+	return ""
+}
+
+func (writer *resumableWriter) SetAuthCookie(value string) {
+	if current := writer.current(); current != nil {
+		current.SetAuthCookie(value)
+	}
+}
+
+func (writer *resumableWriter) GetAuthCookie() string {
+	if current := writer.current(); current != nil {
+		return current.GetAuthCookie()
+	}
+
+	return ""
+}
+
+func (writer *resumableWriter) SetToken(token string) {
+	writer.Lock()
+	writer.base.token = token
+	current := writer.writer
+	writer.Unlock()
+
+	if current != nil {
+		current.SetToken(token)
+	}
+}
+
+func (writer *resumableWriter) Write(result IOperationResult) {
+	if data, err := writer.base.serializer.Serialize(result.Container()); err != nil {
+		writer.base.logger.Error(fmt.Sprintf("RESUMABLE/OR SERIALIZATION ERROR {%s}: %s", writer.base.token, err))
+	} else {
+		writer.WriteBytes(data)
+	}
+}
+
+func (writer *resumableWriter) WriteBytes(data []byte) {
+	writer.writing.Lock()
+	defer writer.writing.Unlock()
+
+	var overflow bool
+	writer.Lock()
+	if writer.base.closed {
+		writer.Unlock()
+		writer.base.logger.Warning("RESUMABLE WRITE ERROR: writer closed")
+		return
+	}
+
+	current := writer.writer
+	if current == nil {
+		if len(writer.queue) < writer.queueSize {
+			writer.queue = append(writer.queue, data)
+		} else {
+			overflow = true
+			writer.base.closed = true
+			writer.queue = nil
+		}
+	}
+	writer.Unlock()
+
+	if overflow {
+		writer.base.logger.Warning(fmt.Sprintf("RESUMABLE WRITE ERROR {%s}: queue overflow", writer.base.token))
+		writer.finalize()
+		return
+	}
+
+	if current != nil {
+		current.WriteBytes(data)
+
+		// The connection dropped under this message, so it is kept for
+		// the replay unless the writer is closed in the meantime.
+		if current.IsClosed() {
+			writer.Lock()
+			if !writer.base.closed && len(writer.queue) < writer.queueSize {
+				writer.queue = append(writer.queue, data)
+			}
+			writer.Unlock()
+		}
+	}
+}
+
+// WriteByte forwards the signal to the attached writer. Signals are not
+// queued since they are meaningless once the moment has passed.
+func (writer *resumableWriter) WriteByte(code byte) error {
+	if current := writer.current(); current != nil {
+		return current.WriteByte(code)
+	}
+
+	return nil
+}
+
+func (writer *resumableWriter) End(result IOperationResult) {
+	writer.writing.Lock()
+	defer writer.writing.Unlock()
+
+	writer.Lock()
+	if writer.base.closed {
+		writer.Unlock()
+		writer.base.logger.Warning("RESUMABLE WRITE ERROR: writer closed")
+		return
+	}
+
+	current := writer.writer
+	writer.base.closed = true
+	writer.writer, writer.queue = nil, nil
+	writer.Unlock()
+
+	if current != nil {
+		current.End(result)
+	}
+}
+
+func (writer *resumableWriter) Serializer() ISerializer {
+	return writer.base.serializer
+}
+
+func (writer *resumableWriter) Close() {
+	writer.Lock()
+	if writer.base.closed {
+		writer.Unlock()
+		return
+	}
+
+	current := writer.writer
+	writer.base.closed = true
+	writer.writer, writer.queue = nil, nil
+	writer.Unlock()
+
+	if current != nil {
+		current.Close()
+	}
+
+	writer.finalize()
+}
+
+func (writer *resumableWriter) current() IWriter {
+	writer.RLock()
+	defer writer.RUnlock()
+
+	return writer.writer
+}
+
+func (writer *resumableWriter) finalize() {
+	if writer.base.onClosed != nil {
+		writer.base.onClosed()
+	}
+}
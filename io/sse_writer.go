@@ -0,0 +1,211 @@
+package io
+
+import (
+	gocontext "context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/labstack/echo/v4"
+	. "github.com/xeronith/diamante/contracts/io"
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/serialization"
+	. "github.com/xeronith/diamante/contracts/server"
+)
+
+type sseWriter struct {
+	sync.RWMutex
+	base         baseWriter
+	context      echo.Context
+	connection   net.Conn
+	secureCookie *securecookie.SecureCookie
+	opened       bool
+}
+
+// CreateSSEWriter creates the writer of a long-lived server-sent events stream.
+// Every message is sent base64 encoded as a default 'message' event, and the
+// result passed to End as an 'end' event. An empty WriteBytes call sends a
+// keep-alive comment. When connection is not nil, its deadlines are managed by
+// the writer so the stream outlives the timeouts of the http server.
+func CreateSSEWriter(
+	server IServer,
+	context echo.Context,
+	connection net.Conn,
+	secureCookie *securecookie.SecureCookie,
+	onClosed func(),
+) IContextWriter {
+	if connection != nil {
+		_ = connection.SetReadDeadline(time.Time{})
+		_ = connection.SetWriteDeadline(time.Time{})
+	}
+
+	return &sseWriter{
+		base:         createBaseWriter(server, onClosed, "application/octet-stream"),
+		context:      context,
+		connection:   connection,
+		secureCookie: secureCookie,
+	}
+}
+
+func (writer *sseWriter) Context() gocontext.Context {
+	return writer.context.Request().Context()
+}
+
+func (writer *sseWriter) ContentType() string {
+	return writer.base.contentType
+}
+
+func (writer *sseWriter) Transport() string {
+	return "sse"
+}
+
+func (writer *sseWriter) IsClosed() bool {
+	return !writer.IsOpen()
+}
+
+func (writer *sseWriter) IsOpen() bool {
+	writer.RLock()
+	defer writer.RUnlock()
+
+	return !writer.base.closed
+}
+
+func (writer *sseWriter) SetSecureCookie(_, _ string) {
+	writer.base.logger.Error("SSE WRITER: SetSecureCookie not supported")
+}
+
+func (writer *sseWriter) GetSecureCookie(key string) string {
+	var value string
+	if cookie, err := writer.context.Request().Cookie(key); err == nil {
+		if err := writer.secureCookie.Decode(key, cookie.Value, &value); err == nil {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func (writer *sseWriter) SetAuthCookie(_ string) {
+	writer.base.logger.Error("SSE WRITER: SetAuthCookie not supported")
+}
+
+func (writer *sseWriter) GetAuthCookie() string {
+	return writer.GetSecureCookie("Diamante")
+}
+
+func (writer *sseWriter) SetToken(token string) {
+	writer.base.token = token
+}
+
+func (writer *sseWriter) Write(result IOperationResult) {
+	writer.writeResult("", result)
+}
+
+func (writer *sseWriter) writeResult(event string, result IOperationResult) {
+	defer writer.catch()
+
+	if data, err := writer.base.serializer.Serialize(result.Container()); err != nil {
+		writer.base.logger.Error(fmt.Sprintf("SSE/OR SERIALIZATION ERROR {%s}: %s", writer.base.token, err))
+	} else {
+		writer.writeEvent(event, data)
+	}
+}
+
+func (writer *sseWriter) WriteByte(code byte) error {
+	writer.writeEvent("signal", []byte{code})
+	return nil
+}
+
+func (writer *sseWriter) WriteBytes(data []byte) {
+	writer.writeEvent("", data)
+}
+
+func (writer *sseWriter) writeEvent(event string, data []byte) {
+	var closed bool
+	func() {
+		defer writer.catch()
+
+		writer.Lock()
+		defer writer.Unlock()
+
+		if writer.base.closed {
+			writer.base.logger.Warning("SSE WRITE ERROR: writer closed")
+			return
+		}
+
+		response := writer.context.Response()
+		if !writer.opened {
+			writer.opened = true
+			response.Header().Set(echo.HeaderContentType, "text/event-stream")
+			response.Header().Set(echo.HeaderCacheControl, "no-cache")
+			response.Header().Set("X-Accel-Buffering", "no")
+			response.WriteHeader(http.StatusOK)
+		}
+
+		if writer.connection != nil {
+			_ = writer.connection.SetWriteDeadline(time.Now().Add(time.Second * 4))
+		}
+
+		var err error
+		switch {
+		case len(data) == 0:
+			_, err = fmt.Fprint(response, ":\n\n")
+		case event == "":
+			_, err = fmt.Fprintf(response, "data: %s\n\n", base64.StdEncoding.EncodeToString(data))
+		default:
+			_, err = fmt.Fprintf(response, "event: %s\ndata: %s\n\n", event, base64.StdEncoding.EncodeToString(data))
+		}
+
+		if err != nil {
+			closed = true
+			writer.base.closed = true
+			writer.base.logger.Error(fmt.Sprintf("SSE/OR WRITE ERROR {%s}: %s", writer.base.token, err))
+			return
+		}
+
+		response.Flush()
+	}()
+
+	if closed {
+		writer.finalize()
+	}
+}
+
+func (writer *sseWriter) End(result IOperationResult) {
+	writer.writeResult("end", result)
+	writer.Close()
+}
+
+func (writer *sseWriter) Serializer() ISerializer {
+	return writer.base.serializer
+}
+
+func (writer *sseWriter) Close() {
+	writer.Lock()
+	if writer.base.closed {
+		writer.Unlock()
+		return
+	}
+
+	writer.base.closed = true
+	writer.Unlock()
+
+	writer.finalize()
+}
+
+func (writer *sseWriter) finalize() {
+	if writer.base.onClosed != nil {
+		writer.base.onClosed()
+	}
+}
+
+func (writer *sseWriter) catch() {
+	if reason := recover(); reason != nil {
+		writer.Close()
+		writer.base.logger.Panic(reason)
+	}
+}
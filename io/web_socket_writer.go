@@ -14,14 +14,23 @@ import (
 
 type webSocketWriter struct {
 	sync.RWMutex
-	base       baseWriter
-	connection *Conn
+	base                 baseWriter
+	connection           *Conn
+	compressionThreshold int
 }
 
 func CreateWebSocketWriter(server IServer, connection *Conn, onClosed func()) IWriter {
+	return CreateCompressedWebSocketWriter(server, connection, 0, onClosed)
+}
+
+// CreateCompressedWebSocketWriter creates a writer that compresses messages of
+// at least compressionThreshold bytes when the connection has negotiated the
+// permessage-deflate extension, and sends smaller messages uncompressed.
+func CreateCompressedWebSocketWriter(server IServer, connection *Conn, compressionThreshold int, onClosed func()) IWriter {
 	return &webSocketWriter{
-		base:       createBaseWriter(server, onClosed, "application/octet-stream"),
-		connection: connection,
+		base:                 createBaseWriter(server, onClosed, "application/octet-stream"),
+		connection:           connection,
+		compressionThreshold: compressionThreshold,
 	}
 }
 
@@ -29,6 +38,10 @@ func (writer *webSocketWriter) ContentType() string {
 	return writer.base.contentType
 }
 
+func (writer *webSocketWriter) Transport() string {
+	return "websocket"
+}
+
 func (writer *webSocketWriter) IsClosed() bool {
 	return !writer.IsOpen()
 }
@@ -100,6 +113,7 @@ func (writer *webSocketWriter) WriteBytes(data []byte) {
 			return
 		}
 
+		writer.connection.EnableWriteCompression(len(data) >= writer.compressionThreshold)
 		if err := writer.connection.WriteMessage(BinaryMessage, data); err != nil {
 			closed = true
 			writer.base.closed = true
@@ -123,6 +137,7 @@ func (writer *webSocketWriter) WriteByte(code byte) error {
 		return nil
 	}
 
+	writer.connection.EnableWriteCompression(false)
 	if err := writer.connection.WriteMessage(BinaryMessage, []byte{code}); err != nil {
 		writer.base.closed = true
 		writer.base.logger.Error(fmt.Sprintf("SOCKET/SIG WRITE ERROR: %s", err))
@@ -143,6 +158,11 @@ func (writer *webSocketWriter) End(operation IOperationResult) {
 			return
 		}
 
+		message := FormatCloseMessage(CloseNormalClosure, "")
+		if err := writer.connection.WriteControl(CloseMessage, message, time.Now().Add(time.Second)); err != nil {
+			writer.base.logger.Warning(fmt.Sprintf("SOCKET CLOSE FRAME ERROR: %s", err))
+		}
+
 		if err := writer.connection.Close(); err != nil {
 			writer.base.logger.Error(fmt.Sprintf("SOCKET CLOSE ERROR: %s", err))
 		}
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// noinspection GoSnakeCaseUsage
+const (
+	FORMAT_JSON   = "json"
+	FORMAT_LOGFMT = "logfmt"
+)
+
+// Field is a key and value of a structured log entry. Entries are lists of
+// fields, so that the keys keep their order in the output.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Format encodes the fields as a single line in the given format, which is
+// either FORMAT_JSON or FORMAT_LOGFMT. Durations are written in milliseconds
+// and times in RFC 3339.
+func Format(format string, fields []Field) string {
+	if format == FORMAT_LOGFMT {
+		return FormatLogfmt(fields)
+	}
+
+	return FormatJSON(fields)
+}
+
+func FormatJSON(fields []Field) string {
+	var builder strings.Builder
+	builder.WriteByte('{')
+	for index, field := range fields {
+		if index > 0 {
+			builder.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(field.Key)
+		value, err := json.Marshal(normalize(field.Value))
+		if err != nil {
+			value, _ = json.Marshal(fmt.Sprint(field.Value))
+		}
+
+		builder.Write(key)
+		builder.WriteByte(':')
+		builder.Write(value)
+	}
+
+	builder.WriteByte('}')
+	return builder.String()
+}
+
+func FormatLogfmt(fields []Field) string {
+	var builder strings.Builder
+	for index, field := range fields {
+		if index > 0 {
+			builder.WriteByte(' ')
+		}
+
+		builder.WriteString(field.Key)
+		builder.WriteByte('=')
+
+		var value string
+		switch normalized := normalize(field.Value).(type) {
+		case nil:
+		case string:
+			value = normalized
+		default:
+			value = fmt.Sprint(normalized)
+		}
+
+		if value == "" || strings.ContainsAny(value, " =\"\t\r\n") {
+			value = strconv.Quote(value)
+		}
+
+		builder.WriteString(value)
+	}
+
+	return builder.String()
+}
+
+func normalize(value interface{}) interface{} {
+	switch value := value.(type) {
+	case time.Duration:
+		return float64(value) / float64(time.Millisecond)
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	case error:
+		return value.Error()
+	default:
+		return value
+	}
+}
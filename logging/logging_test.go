@@ -2,6 +2,7 @@ package logging_test
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/xeronith/diamante/logging"
 )
@@ -14,3 +15,21 @@ func TestLogger(test *testing.T) {
 	logger.Error("Lorem ipsum dolor sit amet.")
 	logger.Critical("Lorem ipsum dolor sit amet.")
 }
+
+func TestFormat(test *testing.T) {
+	fields := []Field{
+		{Key: "op", Value: uint64(100)},
+		{Key: "tag", Value: "GET PROFILE"},
+		{Key: "cached", Value: true},
+		{Key: "duration", Value: 1500 * time.Microsecond},
+		{Key: "error", Value: nil},
+	}
+
+	if line := Format(FORMAT_JSON, fields); line != `{"op":100,"tag":"GET PROFILE","cached":true,"duration":1.5,"error":null}` {
+		test.Fatal(line)
+	}
+
+	if line := Format(FORMAT_LOGFMT, fields); line != `op=100 tag="GET PROFILE" cached=true duration=1.5 error=""` {
+		test.Fatal(line)
+	}
+}
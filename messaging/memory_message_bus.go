@@ -0,0 +1,63 @@
+package messaging
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/xeronith/diamante/contracts/messaging"
+)
+
+var MESSAGE_BUS_CLOSED = errors.New("message_bus_closed")
+
+type memoryMessageBus struct {
+	sync.RWMutex
+	handlers []func([]byte)
+	closed   bool
+}
+
+// NewMemoryMessageBus creates a bus that delivers every message to all of its
+// subscribers within the same process, including the one that published it.
+// It can be shared by several server instances to form an in-process cluster.
+func NewMemoryMessageBus() messaging.IMessageBus {
+	return &memoryMessageBus{
+		handlers: make([]func([]byte), 0),
+	}
+}
+
+func (bus *memoryMessageBus) Publish(data []byte) error {
+	bus.RLock()
+	if bus.closed {
+		bus.RUnlock()
+		return MESSAGE_BUS_CLOSED
+	}
+
+	handlers := make([]func([]byte), len(bus.handlers))
+	copy(handlers, bus.handlers)
+	bus.RUnlock()
+
+	for _, handler := range handlers {
+		handler(data)
+	}
+
+	return nil
+}
+
+func (bus *memoryMessageBus) Subscribe(handler func([]byte)) {
+	if handler == nil {
+		return
+	}
+
+	bus.Lock()
+	defer bus.Unlock()
+
+	bus.handlers = append(bus.handlers, handler)
+}
+
+func (bus *memoryMessageBus) Close() error {
+	bus.Lock()
+	defer bus.Unlock()
+
+	bus.closed = true
+	bus.handlers = nil
+	return nil
+}
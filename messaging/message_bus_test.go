@@ -0,0 +1,123 @@
+package messaging_test
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/xeronith/diamante/messaging"
+)
+
+func TestMemoryMessageBus(test *testing.T) {
+	bus := NewMemoryMessageBus()
+
+	received := make([]string, 0)
+	bus.Subscribe(func(data []byte) { received = append(received, "A:"+string(data)) })
+	bus.Subscribe(func(data []byte) { received = append(received, "B:"+string(data)) })
+
+	if err := bus.Publish([]byte("X")); err != nil {
+		test.Fatal(err)
+	}
+
+	if len(received) != 2 || received[0] != "A:X" || received[1] != "B:X" {
+		test.Fatal(received)
+	}
+
+	_ = bus.Close()
+	if err := bus.Publish([]byte("Y")); err != MESSAGE_BUS_CLOSED {
+		test.Fail()
+	}
+}
+
+func TestTcpMessageBus(test *testing.T) {
+	port := rand.Intn(8999) + 20000
+	addresses := []string{
+		fmt.Sprintf("127.0.0.1:%d", port),
+		fmt.Sprintf("127.0.0.1:%d", port+1),
+		fmt.Sprintf("127.0.0.1:%d", port+2),
+	}
+
+	received := make(chan string, 10)
+	for index, address := range addresses {
+		bus, err := NewTcpMessageBus(address, "secret", addresses...)
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		defer bus.Close()
+
+		name := fmt.Sprintf("%d", index)
+		bus.Subscribe(func(data []byte) { received <- name + ":" + string(data) })
+	}
+
+	sender, err := NewTcpMessageBus(fmt.Sprintf("127.0.0.1:%d", port+3), "secret", addresses...)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	defer sender.Close()
+
+	if err := sender.Publish([]byte("X")); err != nil {
+		test.Fatal(err)
+	}
+
+	deliveries := make(map[string]bool)
+	for len(deliveries) < len(addresses) {
+		select {
+		case delivery := <-received:
+			deliveries[delivery] = true
+		case <-time.After(time.Second * 5):
+			test.Fatal(deliveries)
+		}
+	}
+
+	for index := range addresses {
+		if !deliveries[fmt.Sprintf("%d:X", index)] {
+			test.Fatal(deliveries)
+		}
+	}
+}
+
+func TestTcpMessageBus_Handshake(test *testing.T) {
+	port := rand.Intn(8999) + 20000
+	address := fmt.Sprintf("127.0.0.1:%d", port)
+
+	if _, err := NewTcpMessageBus(address, "", address); err != MESSAGE_BUS_SECRET_REQUIRED {
+		test.Fatal(err)
+	}
+
+	bus, err := NewTcpMessageBus(address, "secret")
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	defer bus.Close()
+
+	received := make(chan string, 10)
+	bus.Subscribe(func(data []byte) { received <- string(data) })
+
+	intruder, err := NewTcpMessageBus(fmt.Sprintf("127.0.0.1:%d", port+1), "guess", address)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	defer intruder.Close()
+
+	connection, err := net.Dial("tcp", address)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	defer connection.Close()
+
+	_ = intruder.Publish([]byte("X"))
+	_, _ = connection.Write(append(make([]byte, 32), 0, 0, 0, 1, 'Y'))
+
+	select {
+	case delivery := <-received:
+		test.Fatal(delivery)
+	case <-time.After(time.Millisecond * 500):
+	}
+}
@@ -0,0 +1,352 @@
+package messaging
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/xeronith/diamante/contracts/logging"
+	"github.com/xeronith/diamante/contracts/messaging"
+	. "github.com/xeronith/diamante/logging"
+)
+
+const (
+	TCP_MESSAGE_BUS_QUEUE_SIZE         = 1024
+	TCP_MESSAGE_BUS_MAX_FRAME_SIZE     = 16 * 1024 * 1024
+	TCP_MESSAGE_BUS_DIAL_INTERVAL      = time.Second
+	TCP_MESSAGE_BUS_HANDSHAKE_TIMEOUT  = time.Second * 4
+	TCP_MESSAGE_BUS_MAX_ACCEPT_BACKOFF = time.Second
+)
+
+var (
+	MESSAGE_TOO_LARGE           = errors.New("message_too_large")
+	MESSAGE_BUS_SECRET_REQUIRED = errors.New("message_bus_secret_required")
+	MESSAGE_BUS_UNAUTHORIZED    = errors.New("message_bus_unauthorized")
+)
+
+type tcpMessageBus struct {
+	sync.RWMutex
+	address     string
+	secret      []byte
+	listener    net.Listener
+	peers       []*tcpPeer
+	connections map[net.Conn]struct{}
+	handlers    []func([]byte)
+	logger      logging.ILogger
+	done        chan struct{}
+	closed      bool
+}
+
+type tcpPeer struct {
+	address    string
+	queue      chan []byte
+	connection net.Conn
+	lastDial   time.Time
+}
+
+// NewTcpMessageBus creates a bus that forms a full mesh with its peers. It
+// listens on address for the messages of the other nodes and sends every
+// published message to each peer over a dedicated connection, as a frame
+// prefixed with its length as a 4-byte big-endian integer. Every connection
+// starts with a handshake: the listening node sends a random 32-byte challenge
+// and the dialing node answers with its HMAC-SHA256 under the shared secret,
+// so only the nodes that know the secret can publish. Delivery is best effort:
+// messages wait in a queue of limited size while a peer is unreachable, and
+// the ones that do not fit are dropped and logged. Peers equal to address are
+// ignored so every node can share the same peer list.
+func NewTcpMessageBus(address, secret string, peers ...string) (messaging.IMessageBus, error) {
+	if secret == "" {
+		return nil, MESSAGE_BUS_SECRET_REQUIRED
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	bus := &tcpMessageBus{
+		address:     address,
+		secret:      []byte(secret),
+		listener:    listener,
+		peers:       make([]*tcpPeer, 0, len(peers)),
+		connections: make(map[net.Conn]struct{}),
+		handlers:    make([]func([]byte), 0),
+		logger:      GetDefaultLogger(),
+		done:        make(chan struct{}),
+	}
+
+	for _, address := range peers {
+		if address == "" || address == bus.address {
+			continue
+		}
+
+		peer := &tcpPeer{
+			address: address,
+			queue:   make(chan []byte, TCP_MESSAGE_BUS_QUEUE_SIZE),
+		}
+
+		bus.peers = append(bus.peers, peer)
+		go bus.send(peer)
+	}
+
+	go bus.accept()
+	return bus, nil
+}
+
+func (bus *tcpMessageBus) Publish(data []byte) error {
+	if len(data) > TCP_MESSAGE_BUS_MAX_FRAME_SIZE {
+		return MESSAGE_TOO_LARGE
+	}
+
+	bus.RLock()
+	defer bus.RUnlock()
+
+	if bus.closed {
+		return MESSAGE_BUS_CLOSED
+	}
+
+	for _, peer := range bus.peers {
+		select {
+		case peer.queue <- data:
+		default:
+			bus.logger.Warning(fmt.Sprintf("MESSAGE BUS: queue of %s is full", peer.address))
+		}
+	}
+
+	return nil
+}
+
+func (bus *tcpMessageBus) Subscribe(handler func([]byte)) {
+	if handler == nil {
+		return
+	}
+
+	bus.Lock()
+	defer bus.Unlock()
+
+	bus.handlers = append(bus.handlers, handler)
+}
+
+func (bus *tcpMessageBus) Close() error {
+	bus.Lock()
+	if bus.closed {
+		bus.Unlock()
+		return nil
+	}
+
+	bus.closed = true
+	close(bus.done)
+
+	for connection := range bus.connections {
+		_ = connection.Close()
+	}
+
+	bus.Unlock()
+
+	return bus.listener.Close()
+}
+
+// send writes the queued messages to the peer. A message that cannot be
+// written is held back, and retried once the peer is dialed again, so the
+// messages published meanwhile wait in the queue.
+func (bus *tcpMessageBus) send(peer *tcpPeer) {
+	defer func() {
+		if peer.connection != nil {
+			_ = peer.connection.Close()
+		}
+	}()
+
+	var data []byte
+	frame := make([]byte, 4)
+	for {
+		if data == nil {
+			select {
+			case <-bus.done:
+				return
+			case data = <-peer.queue:
+			}
+		}
+
+		if peer.connection == nil {
+			if wait := TCP_MESSAGE_BUS_DIAL_INTERVAL - time.Since(peer.lastDial); wait > 0 {
+				select {
+				case <-bus.done:
+					return
+				case <-time.After(wait):
+				}
+			}
+
+			peer.lastDial = time.Now()
+			connection, err := bus.dial(peer.address)
+			if err != nil {
+				bus.logger.Warning(fmt.Sprintf("MESSAGE BUS: %s unreachable: %s", peer.address, err))
+				continue
+			}
+
+			peer.connection = connection
+		}
+
+		binary.BigEndian.PutUint32(frame, uint32(len(data)))
+		_ = peer.connection.SetWriteDeadline(time.Now().Add(time.Second * 4))
+		if _, err := peer.connection.Write(append(frame, data...)); err != nil {
+			bus.logger.Warning(fmt.Sprintf("MESSAGE BUS: write to %s failed: %s", peer.address, err))
+			_ = peer.connection.Close()
+			peer.connection = nil
+			continue
+		}
+
+		data = nil
+	}
+}
+
+// dial connects to the peer and answers its challenge.
+func (bus *tcpMessageBus) dial(address string) (net.Conn, error) {
+	connection, err := net.DialTimeout("tcp", address, time.Second*2)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = connection.SetDeadline(time.Now().Add(TCP_MESSAGE_BUS_HANDSHAKE_TIMEOUT))
+
+	challenge := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(connection, challenge); err != nil {
+		_ = connection.Close()
+		return nil, err
+	}
+
+	if _, err := connection.Write(bus.sign(challenge)); err != nil {
+		_ = connection.Close()
+		return nil, err
+	}
+
+	_ = connection.SetDeadline(time.Time{})
+	return connection, nil
+}
+
+// authenticate challenges a connection accepted from a peer, and fails unless
+// the peer signs the challenge with the shared secret.
+func (bus *tcpMessageBus) authenticate(connection net.Conn) error {
+	_ = connection.SetDeadline(time.Now().Add(TCP_MESSAGE_BUS_HANDSHAKE_TIMEOUT))
+
+	challenge := make([]byte, sha256.Size)
+	if _, err := rand.Read(challenge); err != nil {
+		return err
+	}
+
+	if _, err := connection.Write(challenge); err != nil {
+		return err
+	}
+
+	signature := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(connection, signature); err != nil {
+		return err
+	}
+
+	if !hmac.Equal(signature, bus.sign(challenge)) {
+		return MESSAGE_BUS_UNAUTHORIZED
+	}
+
+	return connection.SetDeadline(time.Time{})
+}
+
+func (bus *tcpMessageBus) sign(challenge []byte) []byte {
+	mac := hmac.New(sha256.New, bus.secret)
+	mac.Write(challenge)
+	return mac.Sum(nil)
+}
+
+// accept serves the connections of the peers until the listener is closed.
+// Temporary failures, such as running out of file descriptors, are retried
+// with an increasing delay.
+func (bus *tcpMessageBus) accept() {
+	var backoff time.Duration
+	for {
+		connection, err := bus.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+
+			if backoff == 0 {
+				backoff = time.Millisecond * 5
+			} else if backoff *= 2; backoff > TCP_MESSAGE_BUS_MAX_ACCEPT_BACKOFF {
+				backoff = TCP_MESSAGE_BUS_MAX_ACCEPT_BACKOFF
+			}
+
+			bus.logger.Error(fmt.Sprintf("MESSAGE BUS ACCEPT ERROR: %s", err))
+
+			select {
+			case <-bus.done:
+				return
+			case <-time.After(backoff):
+			}
+
+			continue
+		}
+
+		backoff = 0
+
+		bus.Lock()
+		if bus.closed {
+			bus.Unlock()
+			_ = connection.Close()
+			return
+		}
+
+		bus.connections[connection] = struct{}{}
+		bus.Unlock()
+
+		go bus.receive(connection)
+	}
+}
+
+func (bus *tcpMessageBus) receive(connection net.Conn) {
+	defer func() {
+		bus.Lock()
+		delete(bus.connections, connection)
+		bus.Unlock()
+
+		_ = connection.Close()
+	}()
+
+	if err := bus.authenticate(connection); err != nil {
+		bus.logger.Warning(fmt.Sprintf("MESSAGE BUS HANDSHAKE ERROR {%s}: %s", connection.RemoteAddr(), err))
+		return
+	}
+
+	reader := bufio.NewReader(connection)
+	var header [4]byte
+	for {
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			return
+		}
+
+		size := binary.BigEndian.Uint32(header[:])
+		if size > TCP_MESSAGE_BUS_MAX_FRAME_SIZE {
+			bus.logger.Error(fmt.Sprintf("MESSAGE BUS READ ERROR {%s}: %s", connection.RemoteAddr(), MESSAGE_TOO_LARGE))
+			return
+		}
+
+		data := make([]byte, size)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return
+		}
+
+		bus.RLock()
+		handlers := make([]func([]byte), len(bus.handlers))
+		copy(handlers, bus.handlers)
+		bus.RUnlock()
+
+		for _, handler := range handlers {
+			handler(data)
+		}
+	}
+}
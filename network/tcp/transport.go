@@ -0,0 +1,215 @@
+package tcp
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	. "github.com/xeronith/diamante/actor"
+	. "github.com/xeronith/diamante/contracts/actor"
+	. "github.com/xeronith/diamante/contracts/server"
+	. "github.com/xeronith/diamante/io"
+)
+
+const (
+	SIGNAL_PING = byte(0x01)
+	SIGNAL_PONG = byte(0x02)
+
+	DEFAULT_HEARTBEAT_INTERVAL = time.Second * 30
+	DEFAULT_MAX_FRAME_SIZE     = 4 * 1024 * 1024
+)
+
+var FRAME_TOO_LARGE = errors.New("frame_too_large")
+
+type transport struct {
+	sync.Mutex
+	port              int
+	heartbeatInterval time.Duration
+	maxFrameSize      uint32
+	listener          net.Listener
+	closed            bool
+}
+
+// NewTransport creates a transport that accepts raw tcp connections and
+// exchanges OperationRequest and OperationResult messages as frames prefixed
+// with their length as a 4-byte big-endian integer. Frames that consist of a
+// single byte are signals: the server sends SIGNAL_PING to idle connections and
+// closes those that stay silent for two heartbeat intervals, and answers a
+// SIGNAL_PING from the client with SIGNAL_PONG.
+func NewTransport(port int) ITransport {
+	return &transport{
+		port:              port,
+		heartbeatInterval: DEFAULT_HEARTBEAT_INTERVAL,
+		maxFrameSize:      DEFAULT_MAX_FRAME_SIZE,
+	}
+}
+
+func CreateTransport(port int, heartbeatInterval time.Duration, maxFrameSize uint32) ITransport {
+	if heartbeatInterval <= 0 {
+		heartbeatInterval = DEFAULT_HEARTBEAT_INTERVAL
+	}
+
+	if maxFrameSize == 0 {
+		maxFrameSize = DEFAULT_MAX_FRAME_SIZE
+	}
+
+	return &transport{
+		port:              port,
+		heartbeatInterval: heartbeatInterval,
+		maxFrameSize:      maxFrameSize,
+	}
+}
+
+func (transport *transport) Name() string {
+	return "tcp"
+}
+
+func (transport *transport) Listen(server IServer) error {
+	listener, err := net.Listen("tcp4", fmt.Sprintf("0.0.0.0:%d", transport.port))
+	if err != nil {
+		return err
+	}
+
+	tlsConfiguration := server.Configuration().GetServerConfiguration().GetTLSConfiguration()
+	if tlsConfiguration.IsEnabled() {
+		certificate, err := tls.LoadX509KeyPair(tlsConfiguration.GetCertFile(), tlsConfiguration.GetKeyFile())
+		if err != nil {
+			_ = listener.Close()
+			return err
+		}
+
+		listener = tls.NewListener(listener, &tls.Config{Certificates: []tls.Certificate{certificate}})
+	}
+
+	transport.Lock()
+	if transport.closed {
+		transport.Unlock()
+		return listener.Close()
+	}
+
+	transport.listener = listener
+	transport.Unlock()
+
+	server.Logger().SysComp(fmt.Sprintf("┄ Listening on port %d (tcp)", transport.port))
+
+	for {
+		connection, err := listener.Accept()
+		if err != nil {
+			if transport.isClosed() {
+				return nil
+			}
+
+			return err
+		}
+
+		go transport.serve(server, connection)
+	}
+}
+
+func (transport *transport) Close() error {
+	transport.Lock()
+	defer transport.Unlock()
+
+	transport.closed = true
+	if transport.listener == nil {
+		return nil
+	}
+
+	return transport.listener.Close()
+}
+
+func (transport *transport) isClosed() bool {
+	transport.Lock()
+	defer transport.Unlock()
+
+	return transport.closed
+}
+
+func (transport *transport) serve(server IServer, connection net.Conn) {
+	remoteAddress, _, _ := net.SplitHostPort(connection.RemoteAddr().String())
+
+	var actor IActor
+	writer := CreateFramedWriter(server, connection, func() {
+		server.OnSocketDisconnected(actor)
+	})
+
+	actor = CreateActor(writer, true, "", remoteAddress, "tcp")
+
+	defer writer.Close()
+	server.OnSocketConnected(actor)
+
+	quit := make(chan struct{})
+	defer close(quit)
+
+	go func() {
+		ticker := time.NewTicker(transport.heartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-quit:
+				return
+			case <-ticker.C:
+				if writer.IsOpen() && time.Since(time.Unix(0, actor.LastActivity())) >= transport.heartbeatInterval {
+					actor.Signal(SIGNAL_PING)
+				}
+			}
+		}
+	}()
+
+	reader := bufio.NewReader(connection)
+	for {
+		if err := connection.SetReadDeadline(time.Now().Add(transport.heartbeatInterval * 2)); err != nil {
+			return
+		}
+
+		frame, err := transport.readFrame(reader)
+		if err != nil {
+			if timeout, ok := err.(net.Error); ok && timeout.Timeout() {
+				return
+			}
+
+			if err != io.EOF && !transport.isClosed() && !writer.IsClosed() {
+				server.Logger().Error(fmt.Sprintf("TCP READ ERROR {%s}: %s", actor.Token(), err))
+			}
+
+			return
+		}
+
+		actor.UpdateLastActivity()
+
+		switch {
+		case len(frame) == 0:
+		case len(frame) == 1 && frame[0] == SIGNAL_PING:
+			actor.Signal(SIGNAL_PONG)
+		case len(frame) == 1:
+		default:
+			actor.Dispatch(server.OnData(actor, frame))
+		}
+	}
+}
+
+func (transport *transport) readFrame(reader io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[:])
+	if size > transport.maxFrameSize {
+		return nil, FRAME_TOO_LARGE
+	}
+
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(reader, frame); err != nil {
+		return nil, err
+	}
+
+	return frame, nil
+}
@@ -0,0 +1,159 @@
+package tcp_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/service"
+	. "github.com/xeronith/diamante/contracts/system"
+	. "github.com/xeronith/diamante/network/tcp"
+	"github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server/servertest"
+	"google.golang.org/protobuf/proto"
+)
+
+type echoOperation struct {
+	operation.Operation
+}
+
+func (operation *echoOperation) Tag() string              { return "ECHO" }
+func (operation *echoOperation) Id() (ID, ID)             { return 100, 101 }
+func (operation *echoOperation) InputContainer() Pointer  { return &protobuf.ServerError{} }
+func (operation *echoOperation) OutputContainer() Pointer { return &protobuf.ServerError{} }
+func (operation *echoOperation) IsCacheable() bool        { return false }
+func (operation *echoOperation) Execute(_ IContext, payload Pointer) (Pointer, error) {
+	return &protobuf.ServerError{Message: payload.(*protobuf.ServerError).Message}, nil
+}
+
+type connection struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func dial(test *testing.T, port int) *connection {
+	test.Helper()
+
+	for deadline := time.Now().Add(servertest.AWAIT_TIMEOUT); ; {
+		conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		if err == nil {
+			test.Cleanup(func() { _ = conn.Close() })
+			return &connection{Conn: conn, reader: bufio.NewReader(conn)}
+		}
+
+		if time.Now().After(deadline) {
+			test.Fatal(err)
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func (connection *connection) write(test *testing.T, frame []byte) {
+	test.Helper()
+
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint32(header, uint32(len(frame)))
+	if _, err := connection.Write(append(header, frame...)); err != nil {
+		test.Fatal(err)
+	}
+}
+
+func (connection *connection) read() ([]byte, error) {
+	_ = connection.SetReadDeadline(time.Now().Add(servertest.AWAIT_TIMEOUT))
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(connection.reader, header); err != nil {
+		return nil, err
+	}
+
+	frame := make([]byte, binary.BigEndian.Uint32(header))
+	if _, err := io.ReadFull(connection.reader, frame); err != nil {
+		return nil, err
+	}
+
+	return frame, nil
+}
+
+func freePort(test *testing.T) int {
+	test.Helper()
+
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestTransport(test *testing.T) {
+	harness := servertest.NewHarness(test, &echoOperation{})
+	harness.SetRole(100, ANONYMOUS)
+
+	port := freePort(test)
+	if err := harness.RegisterTransport(CreateTransport(port, time.Millisecond*100, 1024)); err != nil {
+		test.Fatal(err)
+	}
+
+	harness.Serve()
+
+	test.Run("request", func(test *testing.T) {
+		connection := dial(test, port)
+
+		payload, _ := proto.Marshal(&protobuf.ServerError{Message: "hello"})
+		request, _ := proto.Marshal(&protobuf.OperationRequest{Id: 1, Operation: 100, Payload: payload})
+		connection.write(test, request)
+
+		frame, err := connection.read()
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		result, output := &protobuf.OperationResult{}, &protobuf.ServerError{}
+		if proto.Unmarshal(frame, result) != nil || proto.Unmarshal(result.Payload, output) != nil {
+			test.Fatal(frame)
+		}
+
+		if result.Id != 1 || result.Type != 101 || output.Message != "hello" {
+			test.Fatal(result.Id, result.Type, output.Message)
+		}
+	})
+
+	test.Run("heartbeat", func(test *testing.T) {
+		connection := dial(test, port)
+
+		connection.write(test, []byte{SIGNAL_PING})
+		if frame, err := connection.read(); err != nil || len(frame) != 1 || frame[0] != SIGNAL_PONG {
+			test.Fatal(frame, err)
+		}
+
+		// An idle connection is pinged, and closed when it stays silent.
+		if frame, err := connection.read(); err != nil || len(frame) != 1 || frame[0] != SIGNAL_PING {
+			test.Fatal(frame, err)
+		}
+
+		for {
+			if _, err := connection.read(); err == io.EOF {
+				break
+			} else if err != nil {
+				test.Fatal(err)
+			}
+		}
+	})
+
+	test.Run("frame size", func(test *testing.T) {
+		connection := dial(test, port)
+
+		connection.write(test, make([]byte, 1025))
+		if _, err := connection.read(); err != io.EOF {
+			test.Fatal(err)
+		}
+	})
+}
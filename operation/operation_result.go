@@ -1,6 +1,7 @@
 package operation
 
 import (
+	"net/http"
 	"time"
 
 	. "github.com/xeronith/diamante/contracts/operation"
@@ -15,6 +16,7 @@ type operationResult struct {
 	contentType string
 	duration    time.Duration
 	miss, hit   int64
+	cached      bool
 }
 
 func NewOperationResult() IOperationResult {
@@ -29,7 +31,7 @@ func CreateOperationResult(
 	pipeline IPipeline,
 	duration time.Duration,
 ) IOperationResult {
-	return &operationResult{
+	result := &operationResult{
 		container: protobuf.OperationResult{
 			Id:            id,
 			Status:        status,
@@ -42,6 +44,32 @@ func CreateOperationResult(
 		contentType: pipeline.ContentType(),
 		duration:    duration,
 	}
+
+	if latestVersion := pipeline.ClientLatestVersion(); latestVersion > pipeline.ClientVersion() {
+		result.container.ClientLatestVersion = latestVersion
+	}
+
+	if deprecated, sunset := pipeline.Deprecation(); deprecated {
+		result.container.Deprecated = true
+		if !sunset.IsZero() {
+			result.container.Sunset = sunset.Unix()
+		}
+	}
+
+	return result
+}
+
+// CreatePartialOperationResult creates one of the intermediate results of a
+// streaming operation. The stream is terminated by a regular result.
+func CreatePartialOperationResult(
+	id ID,
+	resultType uint64,
+	payload []byte,
+	pipeline IPipeline,
+) IOperationResult {
+	result := CreateOperationResult(id, http.StatusOK, resultType, payload, pipeline, 0).(*operationResult)
+	result.container.Partial = true
+	return result
 }
 
 func (result *operationResult) Id() uint64 {
@@ -81,16 +109,40 @@ func (result *operationResult) UpdateStat(cached bool, miss, hit int64) IOperati
 		result.duration = 0
 	}
 
+	result.cached = cached
 	result.miss = miss
 	result.hit = hit
 
 	return result
 }
 
+// IsCached reports whether the result was served from the result cache.
+func (result *operationResult) IsCached() bool {
+	return result.cached
+}
+
 func (result *operationResult) Stat() (int64, int64) {
 	return result.miss, result.hit
 }
 
+func (result *operationResult) IsPartial() bool {
+	return result.container.Partial
+}
+
+func (result *operationResult) Deprecation() (bool, time.Time) {
+	if result.container.Sunset == 0 {
+		return result.container.Deprecated, time.Time{}
+	}
+
+	return result.container.Deprecated, time.Unix(result.container.Sunset, 0)
+}
+
+// ClientLatestVersion returns the latest registered version of the client
+// that sent the request, if it is newer than the version the client runs.
+func (result *operationResult) ClientLatestVersion() int32 {
+	return result.container.ClientLatestVersion
+}
+
 func (result *operationResult) Signature() string {
 	return result.container.Hash
 }
@@ -0,0 +1,22 @@
+package operation
+
+import (
+	"errors"
+
+	. "github.com/xeronith/diamante/contracts/service"
+	. "github.com/xeronith/diamante/contracts/system"
+)
+
+// StreamingOperation is the base of operations that implement ExecuteStream
+// and emit their output as a sequence of partial results.
+type StreamingOperation struct {
+	Operation
+}
+
+func (operation *StreamingOperation) Execute(_ IContext, _ Pointer) (Pointer, error) {
+	return nil, errors.New("streaming_operation")
+}
+
+func (operation *StreamingOperation) IsCacheable() bool {
+	return false
+}
@@ -1,16 +1,23 @@
 package server
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"net/http"
+	"path"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
 	. "github.com/xeronith/diamante/actor"
 	. "github.com/xeronith/diamante/contracts/actor"
+	. "github.com/xeronith/diamante/contracts/analytics"
+	. "github.com/xeronith/diamante/contracts/io"
 	. "github.com/xeronith/diamante/io"
 )
 
@@ -22,12 +29,19 @@ func (server *defaultServer) startActiveServer() {
 
 	server.listeners.Append(listener)
 
+	webSocketConfiguration := server.Configuration().GetServerConfiguration().GetWebSocketConfiguration()
+	workers := webSocketConfiguration.GetWorkers()
+	heartbeatInterval := webSocketConfiguration.GetHeartbeatInterval()
+	maxMessageSize := webSocketConfiguration.GetMaxMessageSize()
+	compressionEnabled := webSocketConfiguration.IsCompressionEnabled()
+	compressionLevel := webSocketConfiguration.GetCompressionLevel()
+	compressionThreshold := webSocketConfiguration.GetCompressionThreshold()
+
 	upgrader := websocket.Upgrader{
-		// ReadBufferSize: 1024,
-		// WriteBufferSize: 1024,
-		CheckOrigin: func(request *http.Request) bool {
-			return true
-		},
+		ReadBufferSize:    webSocketConfiguration.GetReadBufferSize(),
+		WriteBufferSize:   webSocketConfiguration.GetWriteBufferSize(),
+		EnableCompression: compressionEnabled,
+		CheckOrigin:       server.checkOrigin,
 	}
 
 	handler := func(context echo.Context) error {
@@ -37,34 +51,81 @@ func (server *defaultServer) startActiveServer() {
 			return err
 		}
 
+		if maxMessageSize > 0 {
+			connection.SetReadLimit(maxMessageSize)
+		}
+
+		if compressionEnabled {
+			if err := connection.SetCompressionLevel(compressionLevel); err != nil {
+				server.logger.Error(fmt.Sprintf("SOCKET COMPRESSION LEVEL: %s", err))
+			}
+		}
+
 		var actor IActor
-		writer := CreateWebSocketWriter(server, connection, func() {
-			server.OnSocketDisconnected(actor)
+		var writer IWriter
+		var final atomic.Bool
+		writer = CreateCompressedWebSocketWriter(server, connection, compressionThreshold, func() {
+			server.onSocketClosed(actor, writer, !final.Load())
 		})
 
-		actor = CreateActor(writer,
-			true,
-			"",
-			context.RealIP(),
-			context.Request().UserAgent(),
-		)
-
 		defer writer.Close()
+
+		actor = server.connectActor(writer, context.QueryParam("resume"), func(writer IWriter) IActor {
+			return CreateActor(writer,
+				true,
+				"",
+				context.RealIP(),
+				context.Request().UserAgent(),
+			)
+		})
+
+		if actor == nil {
+			return nil
+		}
+
 		server.OnSocketConnected(actor)
 
+		var dispatcher *frameDispatcher
+		if workers > 1 {
+			dispatcher = server.createFrameDispatcher(actor, workers)
+			defer dispatcher.Close()
+		}
+
+		if heartbeatInterval > 0 {
+			stopHeartbeat := server.startHeartbeat(connection, actor, writer, heartbeatInterval)
+			defer stopHeartbeat()
+		}
+
 		for {
-			messageType, message, err := connection.ReadMessage()
+			messageType, message, err := readMessage(connection, maxMessageSize)
 			if err != nil {
-				if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseNoStatusReceived, websocket.CloseAbnormalClosure) {
+				var netError net.Error
+				if errors.As(err, &netError) && netError.Timeout() {
+					server.measurement("websocket", Tags{"type": "r", "reason": "heartbeat"}, Fields{"value": 1})
+				} else if errors.Is(err, websocket.ErrReadLimit) {
+					server.logger.Warning(fmt.Sprintf("SERVER SOCKET READ LIMIT {%s}: %d bytes", actor.Token(), maxMessageSize))
+				} else if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+					// The client has ended the session, so there is nothing to resume.
+					final.Store(true)
+				} else if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseNoStatusReceived, websocket.CloseAbnormalClosure) {
 					server.logger.Error(fmt.Sprintf("SERVER SOCKET READ ERROR {%s}: %s", actor.Token(), err))
 				}
 
 				return nil
 			} else {
+				actor.UpdateLastActivity()
+				if heartbeatInterval > 0 {
+					_ = connection.SetReadDeadline(time.Now().Add(heartbeatInterval * 2))
+				}
+
 				switch messageType {
 				case websocket.BinaryMessage, websocket.TextMessage:
-					result := server.OnData(actor, message)
-					actor.Dispatch(result)
+					if dispatcher != nil {
+						dispatcher.Submit(message)
+					} else {
+						result := server.OnData(actor, message)
+						actor.Dispatch(result)
+					}
 				default:
 					server.logger.Error(fmt.Sprintf("UNSUPPORTED SOCKET MESSAGE TYPE: %d", messageType))
 				}
@@ -91,8 +152,103 @@ func (server *defaultServer) startActiveServer() {
 
 	activeServer.GET("/", handler)
 
+	if !server.trackHttpServer(activeServer) {
+		return
+	}
+
 	if err := activeServer.Start(""); err != nil {
 		// server.logger.Critical(fmt.Sprintf("ACTIVE SERVER FAILURE: %s", err))
 		_ = err
 	}
 }
+
+// readMessage reads the next message of the socket. The read limit of the
+// connection applies to the frames on the wire, so compressed messages are
+// inflated through a limited reader and rejected, like the frames, with the
+// 1009 close code as soon as they exceed the limit.
+func readMessage(connection *websocket.Conn, maxMessageSize int64) (int, []byte, error) {
+	messageType, reader, err := connection.NextReader()
+	if err != nil {
+		return messageType, nil, err
+	}
+
+	if maxMessageSize > 0 {
+		reader = io.LimitReader(reader, maxMessageSize+1)
+	}
+
+	message, err := io.ReadAll(reader)
+	if err != nil {
+		return messageType, nil, err
+	}
+
+	if maxMessageSize > 0 && int64(len(message)) > maxMessageSize {
+		closeMessage := websocket.FormatCloseMessage(websocket.CloseMessageTooBig, "")
+		_ = connection.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second))
+		return messageType, nil, websocket.ErrReadLimit
+	}
+
+	return messageType, message, nil
+}
+
+// startHeartbeat pings the socket every interval. The socket is closed by its
+// read deadline when neither the pong nor any other message arrives within two
+// intervals, which catches half-open connections. Pongs count as activity, so
+// the sockets that answer them are not reaped as idle.
+func (server *defaultServer) startHeartbeat(connection *websocket.Conn, actor IActor, writer IWriter, interval time.Duration) func() {
+	timeout := interval * 2
+	_ = connection.SetReadDeadline(time.Now().Add(timeout))
+	connection.SetPongHandler(func(string) error {
+		actor.UpdateLastActivity()
+		return connection.SetReadDeadline(time.Now().Add(timeout))
+	})
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if writer.IsClosed() {
+					return
+				}
+
+				if err := connection.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second*4)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// checkOrigin accepts the websocket upgrade of browser clients only from the
+// allowed origins, which may contain wildcards such as https://*.example.com.
+// Clients that send no origin and servers with no allowed origins are accepted.
+func (server *defaultServer) checkOrigin(request *http.Request) bool {
+	origin := request.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	allowedOrigins := server.Configuration().GetAllowedOrigins()
+	if len(allowedOrigins) == 0 {
+		return true
+	}
+
+	for _, allowedOrigin := range allowedOrigins {
+		if allowedOrigin == "*" || strings.EqualFold(allowedOrigin, origin) {
+			return true
+		}
+
+		if matched, err := path.Match(strings.ToLower(allowedOrigin), strings.ToLower(origin)); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
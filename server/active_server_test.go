@@ -0,0 +1,49 @@
+package server_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/xeronith/diamante/server/servertest"
+	"github.com/xeronith/diamante/settings"
+)
+
+func TestActiveServer_MaxMessageSize(test *testing.T) {
+	harness := servertest.NewHarness(test, &echoOperation{})
+
+	webSocketConfiguration := harness.Configuration().GetServerConfiguration().GetWebSocketConfiguration().(*settings.WebSocket)
+	webSocketConfiguration.MaxMessageSize = 1024
+	webSocketConfiguration.Compression = true
+	harness.Serve()
+
+	for name, compression := range map[string]bool{"frame": false, "inflated": true} {
+		test.Run(name, func(test *testing.T) {
+			dialer := &websocket.Dialer{EnableCompression: compression, HandshakeTimeout: servertest.AWAIT_TIMEOUT}
+			connection, _, err := dialer.Dial(harness.ActiveEndpoint(), nil)
+			if err != nil {
+				test.Fatal(err)
+			}
+
+			defer connection.Close()
+
+			// Zeros compress to a fraction of the limit, so only the inflated
+			// size of the compressed message exceeds it.
+			connection.EnableWriteCompression(compression)
+			if err := connection.WriteMessage(websocket.BinaryMessage, make([]byte, 100*1024)); err != nil {
+				test.Fatal(err)
+			}
+
+			_ = connection.SetReadDeadline(time.Now().Add(servertest.AWAIT_TIMEOUT))
+			for {
+				if _, _, err := connection.ReadMessage(); err != nil {
+					if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+						test.Fatal(err)
+					}
+
+					break
+				}
+			}
+		})
+	}
+}
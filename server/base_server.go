@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/gorilla/securecookie"
+	"github.com/labstack/echo/v4"
 	. "github.com/xeronith/diamante/contracts/email"
 	. "github.com/xeronith/diamante/contracts/io"
 	. "github.com/xeronith/diamante/contracts/localization"
@@ -20,7 +21,9 @@ import (
 
 	. "github.com/xeronith/diamante/contracts/actor"
 	. "github.com/xeronith/diamante/contracts/analytics"
+	. "github.com/xeronith/diamante/contracts/caching"
 	. "github.com/xeronith/diamante/contracts/logging"
+	. "github.com/xeronith/diamante/contracts/messaging"
 	. "github.com/xeronith/diamante/contracts/network/http"
 	. "github.com/xeronith/diamante/contracts/operation"
 	. "github.com/xeronith/diamante/contracts/scheduling"
@@ -29,8 +32,11 @@ import (
 	. "github.com/xeronith/diamante/contracts/server"
 	. "github.com/xeronith/diamante/contracts/service"
 	. "github.com/xeronith/diamante/contracts/system"
+	. "github.com/xeronith/diamante/contracts/throttling"
 	. "github.com/xeronith/diamante/network/http"
+	"github.com/xeronith/diamante/traffic"
 	. "github.com/xeronith/diamante/utility/collections"
+	"google.golang.org/grpc"
 )
 
 type baseServer struct {
@@ -39,28 +45,47 @@ type baseServer struct {
 	hudEnabled              bool
 	activePort, passivePort int
 	diagnosticsPort         int
+	grpcPort                int
 	running                 bool
 	frozen                  bool
+	shuttingDown            bool
+	pendingPipelines        int64
 	listeners               ISlice
 	operations              map[uint64]IOperation
+	versions                map[uint64][]IOperation
+	deprecationWarnings     sync.Map
 	opcodes                 Opcodes
 	configuration           IConfiguration
 	clientRegistry          IStringToIntMap
+	clientMinimumVersions   IStringToIntMap
 	emailProvider           IEmailProvider
 	smsProvider             ISMSProvider
 	securityHandler         ISecurityHandler
 	serializers             map[string]ISerializer
 	scheduler               IScheduler
 	actors                  IStringMap
+	topics                  *topics
+	presence                *presence
+	resumption              *resumption
+	nodeId                  string
+	messageBus              IMessageBus
+	ownedMessageBus         bool
 	logger                  ILogger
 	localizer               ILocalizer
-	cache                   IStringMap
+	cache                   IResultCache
 	cacheMiss               int64
 	cacheHit                int64
 	onStorageUpdated        func(...string)
 	measurementsProvider    IMeasurementsProvider
 	operationRequestPool    *sync.Pool
 	secureCookie            *securecookie.SecureCookie
+	transports              []ITransport
+	interceptors            []IInterceptor
+	rateLimiter             IRateLimiter
+	httpServers             []*echo.Echo
+	grpcServer              *grpc.Server
+	trafficWriter           *traffic.Writer
+	requestLog              *requestLog
 
 	// LEGACY
 	connectedActors      IPointerMap
@@ -172,6 +197,10 @@ func (server *baseServer) Scheduler() IScheduler {
 	return server.scheduler
 }
 
+func (server *baseServer) SetScheduler(scheduler IScheduler) {
+	server.scheduler = scheduler
+}
+
 func (server *baseServer) Serializers() map[string]ISerializer {
 	return server.serializers
 }
@@ -188,6 +217,10 @@ func (server *baseServer) Logger() ILogger {
 	return server.logger
 }
 
+func (server *baseServer) SetLogger(logger ILogger) {
+	server.logger = logger
+}
+
 func (server *baseServer) Opcodes() Opcodes {
 	return server.opcodes
 }
@@ -208,16 +241,24 @@ func (server *baseServer) IncrementActorsCount(actor IActor) {
 }
 
 func (server *baseServer) OnSocketConnected(actor IActor) {
+	actor.UpdateLastActivity()
 	server.connectedActors.Put(actor, "")
+	if actor.Token() != "" || actor.Identity() != nil {
+		server.presence.track(actor)
+	}
+
 	server.measurement("websocket", Tags{"type": "c"}, Fields{"state": 1, "value": server.connectedActors.GetSize()})
 }
 
 func (server *baseServer) OnSocketDisconnected(actor IActor) {
-	/* TODO: if !server.connectedActors.Contains(actor) {
+	if !server.connectedActors.Contains(actor) {
 		return
-	}*/
+	}
 
 	server.connectedActors.Remove(actor)
+	server.topics.removeAll(actor)
+	server.presence.untrack(actor)
+	server.resumption.remove(actor)
 	server.measurement("websocket", Tags{"type": "c"}, Fields{"state": 2, "value": server.connectedActors.GetSize()})
 	if atomic.LoadInt32(&server.connectedActorsCount) > 0 {
 		atomic.AddInt32(&server.connectedActorsCount, -1)
@@ -266,11 +307,19 @@ func (server *baseServer) RegisterOperation(operation IOperation) error {
 		return errors.New("operation ids below 64 are system reserved")
 	}
 
-	if server.operations[operationId] != nil {
-		return fmt.Errorf("operation id %d already registered", operationId)
+	if server.grpcPort > 0 && server.operations[operationId] == nil {
+		if err := server.validateGRPCMethod(operationId, operation); err != nil {
+			return err
+		}
 	}
 
-	server.getOperations()[operationId] = operation
+	if err := server.registerVersion(operationId, operation); err != nil {
+		return err
+	}
+
+	if server.operations[operationId] == nil {
+		server.getOperations()[operationId] = operation
+	}
 
 	return nil
 }
@@ -286,7 +335,7 @@ func (server *baseServer) RegisterOperations(operations ...IOperation) error {
 }
 
 func (server *baseServer) RegisterHttpHandler(handler IHttpHandler) error {
-	if server.running {
+	if server.isRunning() {
 		return errors.New("not allowed to register http handlers when server is running")
 	}
 
@@ -310,6 +359,10 @@ func (server *baseServer) RegisterHttpHandler(handler IHttpHandler) error {
 		return errors.New("not_allowed_to_diagnostics_mem_path")
 	}
 
+	if path == "/events" {
+		return errors.New("not_allowed_to_register_events_path")
+	}
+
 	if method != http.MethodGet && method != http.MethodPost {
 		return fmt.Errorf("method_%s_not_allowed", method)
 	}
@@ -364,9 +417,51 @@ func (server *baseServer) executeService(context IContext, container Pointer, pi
 	operationId := pipeline.Opcode()
 	requestId := pipeline.RequestId()
 
-	defer server.catch(operationId, pipeline.RequestId())
+	type execution struct {
+		output Pointer
+		err    error
+	}
+
+	// The execution counts as a pending pipeline until it returns, even when
+	// it is abandoned after a timeout, so that a graceful shutdown waits for it.
+	atomic.AddInt64(&server.pendingPipelines, 1)
+
+	done := make(chan execution, 1)
+	go func() {
+		defer atomic.AddInt64(&server.pendingPipelines, -1)
+		defer close(done)
+		defer server.catch(operationId, requestId)
+
+		if stream, ok := context.(IStreamContext); ok {
+			done <- execution{nil, operation.(IStreamingOperation).ExecuteStream(stream, container)}
+			return
+		}
+
+		output, err := operation.Execute(context, container)
+		done <- execution{output, err}
+	}()
+
+	var timeout <-chan struct{}
+	if server.executionTimeout(operation) > 0 {
+		timeout = context.Context().Done()
+	}
 
-	output, err := operation.Execute(context, container)
+	var result execution
+	select {
+	case result = <-done:
+	case <-timeout:
+		result.err = GATEWAY_TIMEOUT
+		server.measurement(
+			"operations",
+			Tags{"type": "t"},
+			Fields{
+				"operation": int64(operationId),
+				"requestId": int64(requestId),
+			},
+		)
+	}
+
+	output, err := result.output, result.err
 	duration := server.analyzeOperationPerformance(operation, operationId, context.Timestamp())
 	server.measurement(
 		"operations",
@@ -381,6 +476,22 @@ func (server *baseServer) executeService(context IContext, container Pointer, pi
 	return output, duration, err
 }
 
+// executionTimeout returns how long the operation may run before it is
+// cancelled, or zero when it is not. Streams run for as long as configured,
+// regardless of the time limits meant for single results.
+func (server *baseServer) executionTimeout(operation IOperation) time.Duration {
+	if _, ok := operation.(IStreamingOperation); ok {
+		return server.configuration.GetServerConfiguration().GetStreamingConfiguration().GetTimeout()
+	}
+
+	if cancellable, ok := operation.(ICancellableOperation); !ok || !cancellable.IsCancellable() {
+		return 0
+	}
+
+	_, _, timeLimitCritical := operation.ExecutionTimeLimits()
+	return timeLimitCritical
+}
+
 func (server *baseServer) analyzeOperationPerformance(operation IOperation, operationId uint64, timestamp time.Time) time.Duration {
 	timeLimitWarning, timeLimitAlert, timeLimitCritical := operation.ExecutionTimeLimits()
 	delta := time.Since(timestamp)
@@ -432,6 +543,10 @@ func (server *baseServer) authorize(pipeline IPipeline) error {
 	actor.SetIdentity(identity)
 	actor.UpdateLastActivity()
 
+	if actor.IsActive() && server.connectedActors.Contains(actor) {
+		server.presence.track(actor)
+	}
+
 	return nil
 }
 
@@ -470,7 +585,7 @@ func (server *baseServer) systemCall(identity Identity, args []string) error {
 				return INVALID_PARAMETERS
 			}
 
-			if operation, exists := server.operations[opcode]; !exists {
+			if _, exists := server.operations[opcode]; !exists {
 				return INVALID_PARAMETERS
 			} else {
 				if err := server.securityHandler.AccessControlHandler().
@@ -478,11 +593,20 @@ func (server *baseServer) systemCall(identity Identity, args []string) error {
 					return err
 				}
 
-				operation.SetRole(role)
+				for _, operation := range server.versions[opcode] {
+					operation.SetRole(role)
+				}
+
 				return nil
 			}
 		}
 
+	case "ratelimit":
+		return server.rateLimitSystemCall(args)
+
+	case "client":
+		return server.clientSystemCall(args)
+
 	default:
 		return errors.New("syscall: command_not_found " + args[0])
 	}
@@ -495,6 +619,20 @@ func (server *baseServer) IsFrozen() bool {
 	return server.frozen
 }
 
+func (server *baseServer) isRunning() bool {
+	server.mutex.RLock()
+	defer server.mutex.RUnlock()
+
+	return server.running
+}
+
+func (server *baseServer) IsShuttingDown() bool {
+	server.mutex.RLock()
+	defer server.mutex.RUnlock()
+
+	return server.shuttingDown
+}
+
 func (server *baseServer) measurement(key string, tags Tags, fields Fields) {
 	if server.Configuration().IsDevelopmentEnvironment() {
 		return
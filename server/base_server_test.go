@@ -0,0 +1,71 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/analytics"
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/system"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server"
+	"github.com/xeronith/diamante/server/servertest"
+)
+
+type cancellableOperation struct {
+	*blockingOperation
+}
+
+func (operation *cancellableOperation) Tag() string         { return "CANCELLABLE" }
+func (operation *cancellableOperation) Id() (ID, ID)        { return 330, 331 }
+func (operation *cancellableOperation) IsCancellable() bool { return true }
+func (operation *cancellableOperation) ExecutionTimeLimits() (time.Duration, time.Duration, time.Duration) {
+	return time.Millisecond * 10, time.Millisecond * 20, time.Millisecond * 50
+}
+
+type slowOperation struct {
+	echoOperation
+}
+
+func (operation *slowOperation) Tag() string  { return "SLOW" }
+func (operation *slowOperation) Id() (ID, ID) { return 340, 341 }
+func (operation *slowOperation) ExecutionTimeLimits() (time.Duration, time.Duration, time.Duration) {
+	return time.Millisecond, time.Millisecond, time.Millisecond
+}
+
+func TestExecutionTimeLimit(test *testing.T) {
+	cancellable := &cancellableOperation{newBlockingOperation()}
+	harness := servertest.NewHarness(test, cancellable, &slowOperation{})
+	harness.SetRole(330, ANONYMOUS)
+	harness.SetRole(340, ANONYMOUS)
+
+	// Operations that do not opt in run past their critical time limit.
+	output := &protobuf.ServerError{}
+	if err := harness.Invoke(harness.PassiveActor(nil), 340, &protobuf.ServerError{Message: "slow"}, output); err != nil || output.Message != "slow" {
+		test.Fatal(err, output.Message)
+	}
+
+	if result := harness.Call(harness.PassiveActor(nil), 330, &protobuf.ServerError{}); result.Status() != server.GatewayTimeout {
+		test.Fatal(result.Status())
+	}
+
+	harness.AssertMeasurement("operations", Tags{"type": "t"})
+
+	// The abandoned execution still holds up a graceful shutdown.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	if err := harness.Shutdown(ctx); err != context.DeadlineExceeded {
+		test.Fatal(err)
+	}
+
+	close(cancellable.release)
+
+	ctx, cancel = context.WithTimeout(context.Background(), servertest.AWAIT_TIMEOUT)
+	defer cancel()
+
+	if err := harness.Shutdown(ctx); err != nil {
+		test.Fatal(err)
+	}
+}
@@ -1,18 +1,22 @@
 package server
 
 import (
+	gocontext "context"
 	"errors"
+	"io"
+	"net/http"
 	"time"
 
 	. "github.com/xeronith/diamante/contracts/actor"
 	. "github.com/xeronith/diamante/contracts/analytics"
+	. "github.com/xeronith/diamante/contracts/io"
 	. "github.com/xeronith/diamante/contracts/logging"
 	. "github.com/xeronith/diamante/contracts/messaging"
 	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/presence"
 	. "github.com/xeronith/diamante/contracts/scheduling"
 	. "github.com/xeronith/diamante/contracts/security"
 	. "github.com/xeronith/diamante/contracts/server"
-	. "github.com/xeronith/diamante/contracts/service"
 	. "github.com/xeronith/diamante/contracts/settings"
 	. "github.com/xeronith/diamante/contracts/system"
 	"github.com/xeronith/diamante/utility/concurrent"
@@ -32,12 +36,34 @@ type context struct {
 	clientLatestVersion int32
 	clientName          string
 	timestamp           time.Time
+	ctx                 gocontext.Context
+	cancel              gocontext.CancelFunc
 }
 
 func (server *baseServer) acquireContext(
 	pipeline IPipeline,
-) IContext {
+) *context {
+	var (
+		ctx    gocontext.Context
+		cancel gocontext.CancelFunc
+	)
+
+	// Transports that carry their own deadline or cancellation, such as grpc,
+	// are passed on to the operation along with its own time limit.
+	parent := gocontext.Background()
+	if writer, ok := pipeline.Actor().Writer().(IContextWriter); ok {
+		parent = writer.Context()
+	}
+
+	if timeout := server.executionTimeout(pipeline.Operation()); timeout > 0 {
+		ctx, cancel = gocontext.WithTimeout(parent, timeout)
+	} else {
+		ctx, cancel = gocontext.WithCancel(parent)
+	}
+
 	return &context{
+		ctx:                 ctx,
+		cancel:              cancel,
 		timestamp:           time.Now(),
 		server:              server,
 		operation:           pipeline.Operation(),
@@ -54,6 +80,18 @@ func (server *baseServer) acquireContext(
 	}
 }
 
+func (context *context) release() {
+	context.cancel()
+}
+
+func (context *context) Context() gocontext.Context {
+	return context.ctx
+}
+
+func (context *context) NewHttpRequest(method, url string, body io.Reader) (*http.Request, error) {
+	return http.NewRequestWithContext(context.ctx, method, url, body)
+}
+
 func (context *context) Configuration() IConfiguration {
 	return context.server.configuration
 }
@@ -131,6 +169,10 @@ func (context *context) ActorsCount() int {
 	return context.server.ActorsCount()
 }
 
+func (context *context) Presence() IPresence {
+	return context.server.presence
+}
+
 func (context *context) Scheduler() IScheduler {
 	return context.server.scheduler
 }
@@ -167,6 +209,18 @@ func (context *context) BroadcastSpecific(resultType uint64, payloads map[string
 	return context.server.BroadcastSpecific(resultType, payloads)
 }
 
+func (context *context) Subscribe(topic string) error {
+	return context.server.Subscribe(context.actor, topic)
+}
+
+func (context *context) Unsubscribe(topic string) error {
+	return context.server.Unsubscribe(context.actor, topic)
+}
+
+func (context *context) Publish(topic string, resultType uint64, payload Pointer) error {
+	return context.server.Publish(topic, resultType, payload)
+}
+
 func (context *context) SMS(phoneNumber, message string) error {
 	provider := context.server.SMSProvider()
 	if provider == nil {
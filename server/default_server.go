@@ -1,15 +1,21 @@
 package server
 
 import (
+	gocontext "context"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
 	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	_ "embed"
 
 	"github.com/gorilla/securecookie"
+	"github.com/labstack/echo/v4"
+	. "github.com/xeronith/diamante/caching"
 	"github.com/xeronith/diamante/contracts/analytics"
 	. "github.com/xeronith/diamante/contracts/network/http"
 	. "github.com/xeronith/diamante/contracts/operation"
@@ -19,9 +25,13 @@ import (
 	. "github.com/xeronith/diamante/contracts/system"
 	. "github.com/xeronith/diamante/localization"
 	. "github.com/xeronith/diamante/logging"
+	. "github.com/xeronith/diamante/messaging"
 	"github.com/xeronith/diamante/operation"
 	. "github.com/xeronith/diamante/security"
 	. "github.com/xeronith/diamante/serialization"
+	. "github.com/xeronith/diamante/throttling"
+	"github.com/xeronith/diamante/traffic"
+	"github.com/xeronith/diamante/utility"
 	. "github.com/xeronith/diamante/utility/collections"
 	. "github.com/xeronith/diamante/utility/concurrent"
 )
@@ -41,6 +51,7 @@ func New(configuration IConfiguration, operationFactory IOperationFactory, handl
 	activePort, passivePort, diagnosticsPort := configuration.GetPorts()
 	hashKey := []byte(configuration.GetServerConfiguration().GetHashKey())
 	blockKey := []byte(configuration.GetServerConfiguration().GetBlockKey())
+	cacheConfiguration := configuration.GetServerConfiguration().GetCacheConfiguration()
 
 	serializers := map[string]ISerializer{
 		"application/octet-stream": NewProtobufSerializer(),
@@ -50,42 +61,66 @@ func New(configuration IConfiguration, operationFactory IOperationFactory, handl
 
 	server := &defaultServer{
 		baseServer{
-			opcodes:              opcodes,
-			activePort:           activePort,
-			passivePort:          passivePort,
-			diagnosticsPort:      diagnosticsPort,
-			listeners:            NewConcurrentSlice(),
-			configuration:        configuration,
-			operations:           make(map[uint64]IOperation),
-			securityHandler:      NewDefaultSecurityHandler(),
-			scheduler:            newScheduler(),
-			serializers:          serializers,
-			actors:               NewConcurrentStringMap(),
-			connectedActors:      NewConcurrentPointerMap(),
-			connectedActorsCount: 0,
-			logger:               GetDefaultLogger(),
-			localizer:            NewLocalizer(),
-			cache:                NewConcurrentCircularStringMap(1000),
-			clientRegistry:       NewConcurrentStringToIntMap(),
-			operationRequestPool: &sync.Pool{New: func() interface{} { return operation.NewOperationRequest() }},
-			secureCookie:         securecookie.New(hashKey, blockKey),
-			httpGetHandlers:      make(map[string]IHttpHandler),
-			httpPostHandlers:     make(map[string]IHttpHandler),
-			hudEnabled:           false,
-			onServerStarted:      nil,
-			onActorConnected:     nil,
-			onActorDisconnected:  nil,
+			opcodes:               opcodes,
+			activePort:            activePort,
+			passivePort:           passivePort,
+			diagnosticsPort:       diagnosticsPort,
+			grpcPort:              configuration.GetServerConfiguration().GetPortConfiguration().GetGRPC(),
+			listeners:             NewConcurrentSlice(),
+			configuration:         configuration,
+			operations:            make(map[uint64]IOperation),
+			versions:              make(map[uint64][]IOperation),
+			securityHandler:       NewDefaultSecurityHandler(),
+			scheduler:             newScheduler(),
+			serializers:           serializers,
+			actors:                NewConcurrentStringMap(),
+			topics:                newTopics(),
+			resumption:            newResumption(),
+			nodeId:                utility.GenerateUUID(),
+			connectedActors:       NewConcurrentPointerMap(),
+			connectedActorsCount:  0,
+			logger:                GetDefaultLogger(),
+			localizer:             NewLocalizer(),
+			cache:                 NewResultCache(cacheConfiguration.GetCapacity(), cacheConfiguration.GetTTL()),
+			clientRegistry:        NewConcurrentStringToIntMap(),
+			clientMinimumVersions: NewConcurrentStringToIntMap(),
+			operationRequestPool:  &sync.Pool{New: func() interface{} { return operation.NewOperationRequest() }},
+			secureCookie:          securecookie.New(hashKey, blockKey),
+			httpGetHandlers:       make(map[string]IHttpHandler),
+			httpPostHandlers:      make(map[string]IHttpHandler),
+			interceptors:          make([]IInterceptor, 0),
+			transports:            make([]ITransport, 0),
+			rateLimiter:           NewRateLimiter(NewMemoryRateLimitStore()),
+			hudEnabled:            false,
+			onServerStarted:       nil,
+			onActorConnected:      nil,
+			onActorDisconnected:   nil,
 		},
 	}
 
-	server.onStorageUpdated = func(...string) {
-		server.cache.Clear()
+	server.presence = newPresence(server.actors)
+	server.onStorageUpdated = server.onStorageChanged
+
+	if configuration.IsTrafficRecordEnabled() {
+		trafficConfiguration := configuration.GetServerConfiguration().GetTrafficConfiguration()
+		server.trafficWriter = traffic.CreateWriter(
+			trafficConfiguration.GetPath(),
+			trafficConfiguration.GetMaxFileSize(),
+			trafficConfiguration.GetMaxFiles(),
+		)
+	}
+
+	if configuration.IsRequestLogEnabled() {
+		server.requestLog = newRequestLog(configuration.GetServerConfiguration().GetRequestLogConfiguration(), server.logger)
 	}
 
 	if configuration.IsTestEnvironment() {
 		server.activePort = rand.Intn(8999) + 1000
 		server.passivePort = rand.Intn(8999) + 1000
 		server.diagnosticsPort = rand.Intn(8999) + 1000
+		if server.grpcPort > 0 {
+			server.grpcPort = rand.Intn(8999) + 1000
+		}
 	}
 
 	if operationFactory != nil {
@@ -118,22 +153,39 @@ func (server *defaultServer) Start() {
 		server.Logger().Fatal("Server has no measurements provider.")
 	}
 
+	server.mutex.Lock()
 	if server.running {
+		server.mutex.Unlock()
 		server.Logger().Warning("Server is already running.")
 		return
 	}
 
+	server.running = true
+	server.mutex.Unlock()
+
 	if server.asciiArt != "" {
 		// https://fsymbols.com/generators/tarty/
 		fmt.Println(server.asciiArt)
 	}
 
 	for opcode, role := range server.securityHandler.AccessControlHandler().AccessControls() {
-		if operation, exists := server.operations[opcode]; exists {
+		for _, operation := range server.versions[opcode] {
 			operation.SetRole(role)
 		}
 	}
 
+	if cluster := server.configuration.GetServerConfiguration().GetClusterConfiguration(); cluster.IsEnabled() && server.MessageBus() == nil {
+		bus, err := NewTcpMessageBus(cluster.GetAddress(), cluster.GetSecret(), cluster.GetPeers()...)
+		if err != nil {
+			server.Logger().Fatal(fmt.Sprintf("MESSAGE BUS FATAL ERROR: %s", err))
+		}
+
+		server.SetMessageBus(bus)
+		server.ownedMessageBus = true
+	}
+
+	server.scheduleReaper()
+
 	tasks := CreateAsyncTaskPool(false)
 
 	tasks.Submit(
@@ -141,9 +193,14 @@ func (server *defaultServer) Start() {
 		func() { server.startPassiveServer() },
 		func() { server.startServerScheduler() },
 		func() { server.startDiagnosticsServer() },
+		func() { server.startGRPCServer() },
 	)
 
-	server.running = true
+	for _, transport := range server.transports {
+		transport := transport
+		tasks.Submit(func() { server.startTransport(transport) })
+	}
+
 	server.measurement("core", analytics.Tags{"type": "i"}, analytics.Fields{"event": "0"})
 
 	if server.onServerStarted != nil {
@@ -153,10 +210,106 @@ func (server *defaultServer) Start() {
 	tasks.Run().Join()
 }
 
-func (server *defaultServer) Shutdown() {
+func (server *defaultServer) Shutdown(ctx gocontext.Context) error {
+	server.mutex.Lock()
+	if server.shuttingDown {
+		server.mutex.Unlock()
+		return nil
+	}
+
+	server.shuttingDown = true
+	server.mutex.Unlock()
+
 	server.listeners.ForEach(func(index int, object ISystemObject) {
 		if err := object.(net.Listener).Close(); err != nil {
 			log.Println(err)
 		}
 	})
+
+	server.closeTransports()
+
+	err := server.drain(ctx)
+	if err != nil {
+		server.logger.Warning(fmt.Sprintf("SHUTDOWN: %d pipeline(s) still in flight", atomic.LoadInt64(&server.pendingPipelines)))
+	}
+
+	// The bus is closed only once the pipelines have drained, so that they
+	// can still publish to the other nodes of the cluster.
+	if server.ownedMessageBus {
+		if err := server.MessageBus().Close(); err != nil {
+			server.logger.Error(fmt.Sprintf("MESSAGE BUS CLOSE ERROR: %s", err))
+		}
+	}
+
+	server.disconnectAll(SERVER_SHUTTING_DOWN)
+	server.shutdownHttpServers(ctx)
+	server.stopGRPCServer(ctx)
+	server.scheduler.Stop()
+	server.measurement("core", analytics.Tags{"type": "i"}, analytics.Fields{"event": "1"})
+
+	if server.measurementsProvider != nil {
+		server.measurementsProvider.Flush()
+	}
+
+	if server.trafficWriter != nil {
+		if err := server.trafficWriter.Close(); err != nil {
+			server.logger.Error(fmt.Sprintf("TRAFFIC RECORD CLOSE ERROR: %s", err))
+		}
+	}
+
+	if server.requestLog != nil {
+		if err := server.requestLog.close(); err != nil {
+			server.logger.Error(fmt.Sprintf("REQUEST LOG CLOSE ERROR: %s", err))
+		}
+	}
+
+	server.mutex.Lock()
+	server.running = false
+	server.mutex.Unlock()
+
+	return err
+}
+
+// trackHttpServer keeps an http server of this node to be shut down, and
+// reports false when the node is already shutting down.
+func (server *baseServer) trackHttpServer(httpServer *echo.Echo) bool {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if server.shuttingDown {
+		return false
+	}
+
+	server.httpServers = append(server.httpServers, httpServer)
+	return true
+}
+
+// shutdownHttpServers waits, within the deadline of the context, for the http
+// requests in flight to complete. It runs once the sockets are disconnected,
+// so that the long-lived streams of server-sent events do not hold it up.
+func (server *baseServer) shutdownHttpServers(ctx gocontext.Context) {
+	server.mutex.RLock()
+	httpServers := append([]*echo.Echo(nil), server.httpServers...)
+	server.mutex.RUnlock()
+
+	for _, httpServer := range httpServers {
+		if err := httpServer.Shutdown(ctx); err != nil && !errors.Is(err, net.ErrClosed) {
+			server.logger.Warning(fmt.Sprintf("SHUTDOWN: %s", err))
+		}
+	}
+}
+
+func (server *defaultServer) drain(ctx gocontext.Context) error {
+	ticker := time.NewTicker(time.Millisecond * 10)
+	defer ticker.Stop()
+
+	for atomic.LoadInt64(&server.pendingPipelines) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,113 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/service"
+	. "github.com/xeronith/diamante/contracts/system"
+	"github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server"
+	"github.com/xeronith/diamante/server/servertest"
+)
+
+type blockingOperation struct {
+	operation.Operation
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingOperation() *blockingOperation {
+	return &blockingOperation{
+		started: make(chan struct{}, 16),
+		release: make(chan struct{}),
+	}
+}
+
+func (operation *blockingOperation) Tag() string              { return "BLOCK" }
+func (operation *blockingOperation) Id() (ID, ID)             { return 300, 301 }
+func (operation *blockingOperation) InputContainer() Pointer  { return &protobuf.ServerError{} }
+func (operation *blockingOperation) OutputContainer() Pointer { return &protobuf.ServerError{} }
+func (operation *blockingOperation) IsCacheable() bool        { return false }
+func (operation *blockingOperation) Execute(_ IContext, payload Pointer) (Pointer, error) {
+	operation.started <- struct{}{}
+	<-operation.release
+	return &protobuf.ServerError{Message: payload.(*protobuf.ServerError).Message}, nil
+}
+
+func TestShutdown_Drain(test *testing.T) {
+	blocking := newBlockingOperation()
+	harness := servertest.NewHarness(test, blocking)
+	harness.SetRole(300, ANONYMOUS)
+
+	connected := harness.Connect(nil)
+
+	actor := harness.PassiveActor(nil)
+	request := harness.Request(actor, 300, &protobuf.ServerError{Message: "in flight"})
+
+	results := make(chan IOperationResult, 1)
+	go func() { results <- harness.OnData(actor, request) }()
+	<-blocking.started
+
+	stopped := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), servertest.AWAIT_TIMEOUT)
+		defer cancel()
+		stopped <- harness.Shutdown(ctx)
+	}()
+
+	for !harness.IsShuttingDown() {
+		time.Sleep(time.Millisecond)
+	}
+
+	if result := harness.Call(harness.PassiveActor(nil), 300, &protobuf.ServerError{}); result.Status() != server.ServiceUnavailable {
+		test.Fatal(result.Status())
+	}
+
+	select {
+	case err := <-stopped:
+		test.Fatal("shutdown did not wait for the pipeline in flight", err)
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	close(blocking.release)
+
+	if err := <-stopped; err != nil {
+		test.Fatal(err)
+	}
+
+	output := &protobuf.ServerError{}
+	if result := <-results; result.Status() != server.OK || harness.Decode(result, output) != nil || output.Message != "in flight" {
+		test.Fatal(result.Status(), output.Message)
+	}
+
+	if !connected.IsClosed() {
+		test.Fatal("connected actor not disconnected")
+	}
+
+	harness.AssertMeasurement("core", nil)
+}
+
+func TestShutdown_Deadline(test *testing.T) {
+	blocking := newBlockingOperation()
+	defer close(blocking.release)
+
+	harness := servertest.NewHarness(test, blocking)
+	harness.SetRole(300, ANONYMOUS)
+
+	actor := harness.PassiveActor(nil)
+	request := harness.Request(actor, 300, &protobuf.ServerError{})
+	go harness.OnData(actor, request)
+	<-blocking.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	if err := harness.Shutdown(ctx); err != context.DeadlineExceeded {
+		test.Fatal(err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
@@ -20,14 +21,30 @@ func (server *defaultServer) startDiagnosticsServer() {
 		go server.hud()
 	}
 
+	listener, err := net.Listen("tcp4", fmt.Sprintf("0.0.0.0:%d", server.diagnosticsPort))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	server.listeners.Append(listener)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.DefaultServeMux)
+	mux.HandleFunc("/schema", server.schemaHandler)
+
 	tlsConfiguration := server.Configuration().GetServerConfiguration().GetTLSConfiguration()
 
 	if tlsConfiguration.IsEnabled() {
 		certFile := tlsConfiguration.GetCertFile()
 		keyFile := tlsConfiguration.GetKeyFile()
-		log.Println(http.ListenAndServeTLS(fmt.Sprintf("0.0.0.0:%d", server.diagnosticsPort), certFile, keyFile, nil))
+		err = http.ServeTLS(listener, mux, certFile, keyFile)
 	} else {
-		log.Println(http.ListenAndServe(fmt.Sprintf("0.0.0.0:%d", server.diagnosticsPort), nil))
+		err = http.Serve(listener, mux)
+	}
+
+	if err != nil && !server.IsShuttingDown() {
+		log.Println(err)
 	}
 }
 
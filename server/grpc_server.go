@@ -0,0 +1,295 @@
+package server
+
+import (
+	gocontext "context"
+	"fmt"
+	"log"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/xeronith/diamante/actor"
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/io"
+	. "github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const GRPC_SERVICE_NAME = "diamante.Operations"
+
+var grpcMethodPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// rawCodec hands the payloads of grpc calls over untouched, so that they can
+// be wrapped in operation requests and pass through the regular pipeline.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(value interface{}) ([]byte, error) {
+	return *value.(*[]byte), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, value interface{}) error {
+	*value.(*[]byte) = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+func (server *defaultServer) GRPCEndpoint() string {
+	if server.grpcPort == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%s:%d", server.Configuration().GetServerConfiguration().GetFQDN(), server.grpcPort)
+}
+
+// grpcMethodName is the name of the grpc method of an operation, which is its
+// tag, or Operation followed by the opcode for the operations with no tag.
+func grpcMethodName(opcode uint64, operation IOperation) string {
+	if name := operation.Tag(); name != "" {
+		return name
+	}
+
+	return fmt.Sprintf("Operation%d", opcode)
+}
+
+// validateGRPCMethod rejects the operations whose method name is not a valid
+// identifier, or is already taken by the operation of another opcode.
+func (server *baseServer) validateGRPCMethod(opcode uint64, operation IOperation) error {
+	name := grpcMethodName(opcode, operation)
+	if !grpcMethodPattern.MatchString(name) {
+		return fmt.Errorf("operation id %d has an invalid grpc method name %q", opcode, name)
+	}
+
+	for existingOpcode, existing := range server.operations {
+		if existingOpcode != opcode && grpcMethodName(existingOpcode, existing) == name {
+			return fmt.Errorf("operation ids %d and %d share the grpc method name %q", existingOpcode, opcode, name)
+		}
+	}
+
+	return nil
+}
+
+// grpcServiceDescription exposes every registered operation as a method of
+// a single service named after its tag. Streaming operations are exposed as
+// server streaming methods.
+func (server *defaultServer) grpcServiceDescription() (*grpc.ServiceDesc, error) {
+	description := &grpc.ServiceDesc{
+		ServiceName: GRPC_SERVICE_NAME,
+		HandlerType: (*interface{})(nil),
+		Methods:     make([]grpc.MethodDesc, 0),
+		Streams:     make([]grpc.StreamDesc, 0),
+	}
+
+	for opcode, operation := range server.operations {
+		if err := server.validateGRPCMethod(opcode, operation); err != nil {
+			return nil, err
+		}
+
+		opcode, name := opcode, grpcMethodName(opcode, operation)
+		if _, streaming := operation.(IStreamingOperation); streaming {
+			description.Streams = append(description.Streams, grpc.StreamDesc{
+				StreamName:    name,
+				ServerStreams: true,
+				Handler: func(_ interface{}, stream grpc.ServerStream) error {
+					var payload []byte
+					if err := stream.RecvMsg(&payload); err != nil {
+						return err
+					}
+
+					_, err := server.serveGRPC(stream.Context(), stream, opcode, payload)
+					return err
+				},
+			})
+		} else {
+			description.Methods = append(description.Methods, grpc.MethodDesc{
+				MethodName: name,
+				Handler: func(_ interface{}, ctx gocontext.Context, decode func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+					var payload []byte
+					if err := decode(&payload); err != nil {
+						return nil, err
+					}
+
+					return server.serveGRPC(ctx, nil, opcode, payload)
+				},
+			})
+		}
+	}
+
+	return description, nil
+}
+
+// serveGRPC wraps the payload of a grpc call in an operation request and hands
+// it to OnData. The token, client and api versions are read from the metadata.
+func (server *defaultServer) serveGRPC(ctx gocontext.Context, stream grpc.ServerStream, opcode uint64, payload []byte) (*[]byte, error) {
+	incoming, _ := metadata.FromIncomingContext(ctx)
+	value := func(key string) string {
+		if values := incoming.Get(key); len(values) > 0 {
+			return values[0]
+		}
+
+		return ""
+	}
+
+	remoteAddress := ""
+	if info, ok := peer.FromContext(ctx); ok && info.Addr != nil {
+		remoteAddress, _, _ = net.SplitHostPort(info.Addr.String())
+	}
+
+	requestId, err := strconv.ParseUint(value("x-request-id"), 10, 64)
+	if err != nil {
+		requestId = uint64(time.Now().UnixNano())
+	}
+
+	clientVersion, _ := strconv.ParseInt(value("x-client-version"), 10, 32)
+	apiVersion, _ := strconv.ParseInt(value("x-api-version"), 10, 32)
+	token := strings.TrimPrefix(value("authorization"), "Bearer ")
+
+	writer := CreateGRPCWriter(server, ctx, stream)
+	actor := CreateActor(writer, false, "", remoteAddress, value("user-agent"))
+	defer writer.Close()
+
+	request := CreateOperationRequest(requestId, opcode, value("x-client-name"), int32(clientVersion), int32(apiVersion), token, payload)
+	data, err := actor.Serializer().Serialize(request.Container())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	result := server.OnData(actor, data)
+
+	trailer := metadata.Pairs("x-request-id", strconv.FormatUint(result.Id(), 10))
+	if latestVersion := result.ClientLatestVersion(); latestVersion > 0 {
+		trailer.Set("x-update-available", strconv.Itoa(int(latestVersion)))
+	}
+
+	if deprecated, sunset := result.Deprecation(); deprecated {
+		trailer.Set("deprecation", "true")
+		if !sunset.IsZero() {
+			trailer.Set("sunset", sunset.UTC().Format(time.RFC3339))
+		}
+	}
+
+	if result.Status() == OK {
+		_ = grpc.SetTrailer(ctx, trailer)
+		output := result.Payload()
+		return &output, nil
+	}
+
+	return nil, server.grpcError(ctx, result, trailer)
+}
+
+func (server *defaultServer) grpcError(ctx gocontext.Context, result IOperationResult, trailer metadata.MD) error {
+	serverError := &protobuf.ServerError{}
+	if err := server.serializers["application/octet-stream"].Deserialize(result.Payload(), serverError); err != nil {
+		serverError.Message = INTERNAL_SERVER_ERROR.Error()
+	}
+
+	if serverError.RetryAfter > 0 {
+		trailer.Set("retry-after", strconv.FormatInt((serverError.RetryAfter+999)/1000, 10))
+	}
+
+	_ = grpc.SetTrailer(ctx, trailer)
+
+	code := codes.Unknown
+	switch result.Status() {
+	case BadRequest:
+		code = codes.InvalidArgument
+	case Unauthorized:
+		code = codes.Unauthenticated
+	case NotImplemented:
+		code = codes.Unimplemented
+	case Gone, UpgradeRequired:
+		code = codes.FailedPrecondition
+	case TooManyRequests:
+		code = codes.ResourceExhausted
+	case ServiceUnavailable:
+		code = codes.Unavailable
+	case GatewayTimeout:
+		code = codes.DeadlineExceeded
+	case InternalServerError:
+		code = codes.Internal
+	}
+
+	return status.Error(code, serverError.Message)
+}
+
+func (server *defaultServer) startGRPCServer() {
+	if server.grpcPort == 0 {
+		return
+	}
+
+	listener, err := net.Listen("tcp4", fmt.Sprintf("0.0.0.0:%d", server.grpcPort))
+	if err != nil {
+		log.Fatalf("GRPC SERVER LISTENER FATAL ERROR: %s", err)
+	}
+
+	server.listeners.Append(listener)
+
+	options := []grpc.ServerOption{grpc.ForceServerCodec(rawCodec{})}
+
+	tlsConfiguration := server.Configuration().GetServerConfiguration().GetTLSConfiguration()
+	if tlsConfiguration.IsEnabled() {
+		transportCredentials, err := credentials.NewServerTLSFromFile(tlsConfiguration.GetCertFile(), tlsConfiguration.GetKeyFile())
+		if err != nil {
+			log.Fatalf("GRPC SERVER TLS FATAL ERROR: %s", err)
+		}
+
+		options = append(options, grpc.Creds(transportCredentials))
+	}
+
+	description, err := server.grpcServiceDescription()
+	if err != nil {
+		log.Fatalf("GRPC SERVER FATAL ERROR: %s", err)
+	}
+
+	grpcServer := grpc.NewServer(options...)
+	grpcServer.RegisterService(description, nil)
+
+	server.mutex.Lock()
+	if server.shuttingDown {
+		server.mutex.Unlock()
+		return
+	}
+
+	server.grpcServer = grpcServer
+	server.mutex.Unlock()
+
+	server.logger.SysComp(fmt.Sprintf("┄ Listening on port %d (gRPC)", server.grpcPort))
+	if err := grpcServer.Serve(listener); err != nil && !server.IsShuttingDown() {
+		log.Println(err)
+	}
+}
+
+// stopGRPCServer waits, within the deadline of the context, for the grpc calls
+// in flight to complete, and then cuts off the ones that are left.
+func (server *baseServer) stopGRPCServer(ctx gocontext.Context) {
+	server.mutex.RLock()
+	grpcServer := server.grpcServer
+	server.mutex.RUnlock()
+
+	if grpcServer == nil {
+		return
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+		server.logger.Warning("SHUTDOWN: grpc calls still in flight were cut off")
+	}
+}
@@ -0,0 +1,150 @@
+package server_test
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"testing"
+
+	. "github.com/xeronith/diamante/contracts/security"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server"
+	"github.com/xeronith/diamante/server/servertest"
+	"github.com/xeronith/diamante/settings"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// bytesCodec sends and receives the payloads of the calls as they are, like
+// the codec of the server does.
+type bytesCodec struct{}
+
+func (bytesCodec) Marshal(value interface{}) ([]byte, error) {
+	return *value.(*[]byte), nil
+}
+
+func (bytesCodec) Unmarshal(data []byte, value interface{}) error {
+	*value.(*[]byte) = append([]byte(nil), data...)
+	return nil
+}
+
+func (bytesCodec) Name() string {
+	return "proto"
+}
+
+func TestGRPC(test *testing.T) {
+	configuration := settings.NewTestConfiguration()
+	configuration.GetServerConfiguration().GetPortConfiguration().(*settings.Ports).GRPC = 1
+
+	harness := servertest.NewHarnessWithConfiguration(test, configuration, &echoOperation{}, newStreamOperation())
+	harness.SetRole(100, ANONYMOUS)
+	harness.SetRole(350, ANONYMOUS)
+	harness.Serve()
+
+	connection, err := grpc.Dial(harness.GRPCEndpoint(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(bytesCodec{})),
+	)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	defer connection.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), servertest.AWAIT_TIMEOUT)
+	defer cancel()
+
+	call := func(message string) (*protobuf.ServerError, metadata.MD, error) {
+		test.Helper()
+
+		input, _ := proto.Marshal(&protobuf.ServerError{Message: message})
+		ctx := metadata.AppendToOutgoingContext(ctx, "x-request-id", "7")
+
+		var data []byte
+		trailer := metadata.MD{}
+		if err := connection.Invoke(ctx, "/"+server.GRPC_SERVICE_NAME+"/ECHO", &input, &data, grpc.Trailer(&trailer)); err != nil {
+			return nil, trailer, err
+		}
+
+		output := &protobuf.ServerError{}
+		if err := proto.Unmarshal(data, output); err != nil {
+			test.Fatal(err)
+		}
+
+		return output, trailer, nil
+	}
+
+	test.Run("unary", func(test *testing.T) {
+		output, trailer, err := call("echo")
+		if err != nil || output.Message != "echo" {
+			test.Fatal(err, output)
+		}
+
+		if requestId := trailer.Get("x-request-id"); len(requestId) != 1 || requestId[0] != "7" {
+			test.Fatal(requestId)
+		}
+	})
+
+	test.Run("error", func(test *testing.T) {
+		harness.RateLimiter().SetLimit(100, ANONYMOUS, 0.001, 1)
+
+		if _, _, err := call("allowed"); err != nil {
+			test.Fatal(err)
+		}
+
+		_, trailer, err := call("throttled")
+		if status.Code(err) != codes.ResourceExhausted {
+			test.Fatal(err)
+		}
+
+		retryAfter := trailer.Get("retry-after")
+		if len(retryAfter) != 1 {
+			test.Fatal(trailer)
+		}
+
+		if seconds, err := strconv.Atoi(retryAfter[0]); err != nil || seconds <= 0 {
+			test.Fatal(retryAfter, err)
+		}
+	})
+
+	test.Run("stream", func(test *testing.T) {
+		stream, err := connection.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, "/"+server.GRPC_SERVICE_NAME+"/STREAM")
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		input, _ := proto.Marshal(&protobuf.ServerError{Message: "stream"})
+		if err := stream.SendMsg(&input); err != nil {
+			test.Fatal(err)
+		}
+
+		if err := stream.CloseSend(); err != nil {
+			test.Fatal(err)
+		}
+
+		messages := make([]string, 0)
+		for {
+			var data []byte
+			if err := stream.RecvMsg(&data); err == io.EOF {
+				break
+			} else if err != nil {
+				test.Fatal(err)
+			}
+
+			output := &protobuf.ServerError{}
+			if err := proto.Unmarshal(data, output); err != nil {
+				test.Fatal(err)
+			}
+
+			messages = append(messages, output.Message)
+		}
+
+		if len(messages) != 2 || messages[0] != "stream1" || messages[1] != "stream2" {
+			test.Fatal(messages)
+		}
+	})
+}
@@ -52,6 +52,10 @@ func (server *defaultServer) startPassiveServer() {
 			"X-Turbo",
 			"X-Note",
 			"X-Quote",
+			"X-Update-Available",
+			"Deprecation",
+			"Sunset",
+			"Retry-After",
 		},
 		AllowMethods:     []string{"POST"},
 		AllowCredentials: true,
@@ -149,6 +153,7 @@ func (server *defaultServer) startPassiveServer() {
 
 	passiveServer.POST("/", defaultHandler)
 	passiveServer.POST("/diagnostics/:clientType", diagnosticsHandler)
+	passiveServer.GET("/events", server.eventsHandler)
 
 	const (
 		UPLOAD_PATH     = "./media"
@@ -406,6 +411,11 @@ func (server *defaultServer) startPassiveServer() {
 
 	passiveServer.Server.ReadTimeout = time.Second * 15
 	passiveServer.Server.WriteTimeout = time.Second * 15
+	passiveServer.Server.ConnContext = connectionContext
+
+	if !server.trackHttpServer(passiveServer) {
+		return
+	}
 
 	server.logger.SysComp(fmt.Sprintf("┄ Listening on port %d", server.passivePort))
 	if err := passiveServer.Start(""); err != nil {
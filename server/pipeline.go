@@ -3,6 +3,7 @@ package server
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/go-faster/city"
 	. "github.com/xeronith/diamante/contracts/actor"
@@ -34,7 +35,7 @@ type pipeline struct {
 
 func NewPipeline(server *baseServer, actor IActor, request IOperationRequest) IPipeline {
 	contentType := actor.Writer().ContentType()
-	operation := server.operations[request.Operation()]
+	operation := server.resolveOperation(request.Operation(), request.ApiVersion())
 
 	var serializer ISerializer
 	if contentSerializer, ok := server.serializers[contentType]; !ok {
@@ -105,6 +106,14 @@ func (pipeline *pipeline) ApiVersion() int32 {
 	return pipeline.apiVersion
 }
 
+func (pipeline *pipeline) Deprecation() (bool, time.Time) {
+	if operation, ok := pipeline.operation.(IVersionedOperation); ok {
+		return operation.Deprecation()
+	}
+
+	return false, time.Time{}
+}
+
 func (pipeline *pipeline) ServerVersion() int32 {
 	return pipeline.serverVersion
 }
@@ -114,7 +123,7 @@ func (pipeline *pipeline) ClientVersion() int32 {
 }
 
 func (pipeline *pipeline) ClientLatestVersion() int32 {
-	return pipeline.clientVersion
+	return pipeline.clientLatestVersion
 }
 
 func (pipeline *pipeline) ClientName() string {
@@ -134,12 +143,22 @@ func (pipeline *pipeline) Sign(payload []byte) string {
 		return ""
 	}
 
+	return fmt.Sprintf("%s%x", pipeline.RequestHash(), city.Hash64(payload))
+}
+
+// RequestHash identifies the request by its token, payload, opcode and api
+// version, since each api version of an opcode may have its own result.
+func (pipeline *pipeline) RequestHash() string {
+	if pipeline.request == nil {
+		return ""
+	}
+
 	return fmt.Sprintf(
-		"%x%x%x%x",
+		"%x%x%x%.8x",
 		city.Hash64([]byte(pipeline.actor.Token())),
 		city.Hash64(pipeline.request.Payload()),
 		pipeline.opcode,
-		city.Hash64(payload),
+		uint32(pipeline.apiVersion),
 	)
 }
 
@@ -148,10 +167,5 @@ func (pipeline *pipeline) IsAcceptable(result IOperationResult) bool {
 		return false
 	}
 
-	return strings.HasPrefix(result.Signature(), fmt.Sprintf(
-		"%x%x%x",
-		city.Hash64([]byte(pipeline.actor.Token())),
-		city.Hash64(pipeline.request.Payload()),
-		pipeline.opcode,
-	))
+	return strings.HasPrefix(result.Signature(), pipeline.RequestHash())
 }
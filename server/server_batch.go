@@ -0,0 +1,101 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/server"
+	. "github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+)
+
+// processBatch fans the requests of a batch envelope out through the regular
+// pipeline and collects their results in the order of the requests. Requests
+// that leave the token or client information empty inherit it from the envelope.
+// Concurrent batches run at most the configured number of requests at a time.
+func (server *baseServer) processBatch(pipeline IPipeline, envelope IOperationRequest) IOperationResult {
+	timestamp := time.Now()
+
+	batch := &protobuf.OperationBatchRequest{}
+	if err := pipeline.Serializer().Deserialize(envelope.Payload(), batch); err != nil {
+		return pipeline.BadRequest(err)
+	}
+
+	configuration := server.configuration.GetServerConfiguration().GetBatchConfiguration()
+	if len(batch.Requests) > configuration.GetMaxSize() {
+		return pipeline.BadRequest(BATCH_REQUEST_TOO_LARGE)
+	}
+
+	sequential := batch.Sequential
+	requests := make([]IOperationRequest, len(batch.Requests))
+	for index, item := range batch.Requests {
+		if item == nil {
+			return pipeline.BadRequest()
+		}
+
+		if item.Operation == BATCH_REQUEST {
+			return pipeline.BadRequest(NESTED_BATCH_REQUEST)
+		}
+
+		// The requests share the actor of the envelope, which holds a single
+		// token and identity, so they cannot carry a token of their own.
+		token, clientName, clientVersion, apiVersion := item.Token, item.ClientName, item.ClientVersion, item.ApiVersion
+		if token == "" {
+			token = envelope.Token()
+		} else if token != envelope.Token() {
+			return pipeline.BadRequest(BATCH_TOKEN_MISMATCH)
+		}
+
+		if clientName == "" {
+			clientName, clientVersion = envelope.ClientName(), envelope.ClientVersion()
+		}
+
+		if apiVersion == 0 {
+			apiVersion = envelope.ApiVersion()
+		}
+
+		if _, ok := server.resolveOperation(item.Operation, apiVersion).(IStreamingOperation); ok {
+			return pipeline.BadRequest(STREAMING_IN_BATCH_REQUEST)
+		}
+
+		if server.isSequential(item.Operation, apiVersion) {
+			sequential = true
+		}
+
+		requests[index] = CreateOperationRequest(item.Id, item.Operation, clientName, clientVersion, apiVersion, token, item.Payload)
+	}
+
+	actor := pipeline.Actor()
+	results := make([]*protobuf.OperationResult, len(requests))
+
+	if sequential {
+		for index, request := range requests {
+			results[index] = server.handleRequest(actor, request).Container().(*protobuf.OperationResult)
+		}
+	} else {
+		var waitGroup sync.WaitGroup
+		workers := make(chan struct{}, configuration.GetWorkers())
+		for index, request := range requests {
+			waitGroup.Add(1)
+			workers <- struct{}{}
+			go func(index int, request IOperationRequest) {
+				defer func() {
+					<-workers
+					waitGroup.Done()
+				}()
+
+				results[index] = server.handleRequest(actor, request).Container().(*protobuf.OperationResult)
+			}(index, request)
+		}
+
+		waitGroup.Wait()
+	}
+
+	payload, err := pipeline.Serializer().Serialize(&protobuf.OperationBatchResult{Results: results})
+	if err != nil {
+		return pipeline.InternalServerError(err)
+	}
+
+	return CreateOperationResult(pipeline.RequestId(), OK, BATCH_RESULT, payload, pipeline, time.Since(timestamp))
+}
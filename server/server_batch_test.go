@@ -0,0 +1,117 @@
+package server_test
+
+import (
+	"testing"
+
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/server"
+	. "github.com/xeronith/diamante/contracts/throttling"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server"
+	"github.com/xeronith/diamante/server/servertest"
+)
+
+func TestBatch(test *testing.T) {
+	harness := servertest.NewHarness(test, &echoOperation{})
+	harness.SetRole(100, USER)
+
+	owner, other := harness.NewIdentity(1, USER), harness.NewIdentity(2, USER)
+	actor := harness.PassiveActor(owner)
+
+	item := func(id uint64, token, message string) *protobuf.OperationRequest {
+		payload, err := actor.Serializer().Serialize(&protobuf.ServerError{Message: message})
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		return &protobuf.OperationRequest{Id: id, Operation: 100, Token: token, Payload: payload}
+	}
+
+	assertError := func(result IOperationResult, status int32, message string) {
+		test.Helper()
+
+		serverError := &protobuf.ServerError{}
+		if result.Status() != status || harness.Decode(result, serverError) != nil || serverError.Message != message {
+			test.Fatal(result.Status(), serverError.Message)
+		}
+	}
+
+	// Requests without a token run as the actor of the envelope.
+	result := harness.Call(actor, BATCH_REQUEST, &protobuf.OperationBatchRequest{
+		Requests: []*protobuf.OperationRequest{item(1, "", "first"), item(2, owner.Token(), "second")},
+	})
+
+	batch := &protobuf.OperationBatchResult{}
+	if result.Status() != server.OK || result.Type() != BATCH_RESULT || harness.Decode(result, batch) != nil || len(batch.Results) != 2 {
+		test.Fatal(result.Status(), result.Type())
+	}
+
+	for index, expected := range []string{"first", "second"} {
+		output := &protobuf.ServerError{}
+		if batch.Results[index].Status != server.OK || actor.Serializer().Deserialize(batch.Results[index].Payload, output) != nil || output.Message != expected {
+			test.Fatal(index, batch.Results[index].Status, output.Message)
+		}
+	}
+
+	// A request cannot borrow the token of another identity.
+	result = harness.Call(actor, BATCH_REQUEST, &protobuf.OperationBatchRequest{
+		Requests: []*protobuf.OperationRequest{item(1, "", "first"), item(2, other.Token(), "second")},
+	})
+
+	assertError(result, server.BadRequest, "batch_token_mismatch")
+
+	requests := make([]*protobuf.OperationRequest, harness.Configuration().GetServerConfiguration().GetBatchConfiguration().GetMaxSize()+1)
+	for index := range requests {
+		requests[index] = item(uint64(index), "", "")
+	}
+
+	result = harness.Call(actor, BATCH_REQUEST, &protobuf.OperationBatchRequest{Requests: requests})
+	assertError(result, server.BadRequest, "batch_request_too_large")
+}
+
+func TestBatch_Envelope(test *testing.T) {
+	harness := servertest.NewHarness(test, &echoOperation{})
+	harness.SetRole(100, ANONYMOUS)
+	harness.RateLimiter().SetLimit(ANY_OPCODE, ANY_ROLE, 0.001, 2)
+
+	opcodes := make([]uint64, 0)
+	if err := harness.RegisterInterceptor(server.NewInterceptor(
+		func(pipeline IPipeline, _ IOperationRequest) IOperationResult {
+			opcodes = append(opcodes, pipeline.Opcode())
+			return nil
+		}, nil,
+	)); err != nil {
+		test.Fatal(err)
+	}
+
+	actor := harness.PassiveActor(nil)
+	payload, _ := actor.Serializer().Serialize(&protobuf.ServerError{})
+	batch := &protobuf.OperationBatchRequest{Sequential: true}
+	for id := uint64(1); id <= 3; id++ {
+		batch.Requests = append(batch.Requests, &protobuf.OperationRequest{Id: id, Operation: 100, Payload: payload})
+	}
+
+	// The envelope takes a token of its own and every request one of the
+	// bucket of its operation.
+	for _, expected := range [][]int32{{server.OK, server.OK, server.TooManyRequests}, {server.TooManyRequests}} {
+		result, batchResult := harness.Call(actor, BATCH_REQUEST, batch), &protobuf.OperationBatchResult{}
+		if result.Status() != server.OK || harness.Decode(result, batchResult) != nil {
+			test.Fatal(result.Status())
+		}
+
+		for index, status := range expected {
+			if batchResult.Results[index].Status != status {
+				test.Fatal(index, batchResult.Results[index].Status)
+			}
+		}
+	}
+
+	if len(opcodes) != 8 || opcodes[0] != BATCH_REQUEST || opcodes[1] != 100 || opcodes[4] != BATCH_REQUEST {
+		test.Fatal(opcodes)
+	}
+
+	if result := harness.Call(actor, BATCH_REQUEST, batch); result.Status() != server.TooManyRequests {
+		test.Fatal(result.Status())
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"fmt"
+
+	. "github.com/xeronith/diamante/contracts/messaging"
+	. "github.com/xeronith/diamante/protobuf"
+)
+
+const (
+	BUS_BROADCAST  = 1
+	BUS_PUSH       = 2
+	BUS_PUBLISH    = 3
+	BUS_INVALIDATE = 4
+)
+
+func (server *baseServer) MessageBus() IMessageBus {
+	server.mutex.RLock()
+	defer server.mutex.RUnlock()
+
+	return server.messageBus
+}
+
+// SetMessageBus connects the server to the other nodes of a cluster, so
+// broadcasts, pushes by token, topic publications and cache invalidations
+// reach the actors and caches of every node. The bus remains owned by the
+// caller and is not closed when the server shuts down.
+func (server *baseServer) SetMessageBus(bus IMessageBus) {
+	server.mutex.Lock()
+	server.messageBus = bus
+	server.mutex.Unlock()
+
+	if bus != nil {
+		bus.Subscribe(server.onBusMessage)
+	}
+}
+
+func (server *baseServer) publishToBus(kind uint32, target string, payload []byte, arguments ...string) {
+	bus := server.MessageBus()
+	if bus == nil {
+		return
+	}
+
+	message := &BusMessage{
+		Origin:    server.nodeId,
+		Kind:      kind,
+		Target:    target,
+		Payload:   payload,
+		Arguments: arguments,
+	}
+
+	data, err := server.serializers["application/octet-stream"].Serialize(message)
+	if err != nil {
+		server.logger.Error(err)
+		return
+	}
+
+	if err := bus.Publish(data); err != nil {
+		server.logger.Error(fmt.Sprintf("MESSAGE BUS PUBLISH ERROR: %s", err))
+	}
+}
+
+func (server *baseServer) onBusMessage(data []byte) {
+	message := &BusMessage{}
+	if err := server.serializers["application/octet-stream"].Deserialize(data, message); err != nil {
+		server.logger.Error(fmt.Sprintf("MESSAGE BUS READ ERROR: %s", err))
+		return
+	}
+
+	if message.Origin == server.nodeId {
+		return
+	}
+
+	switch message.Kind {
+	case BUS_BROADCAST:
+		_ = server.broadcast(message.Payload)
+	case BUS_PUSH:
+		server.pushToken(message.Target, message.Payload)
+	case BUS_PUBLISH:
+		server.publish(message.Target, message.Payload)
+	case BUS_INVALIDATE:
+		server.invalidateCache(message.Arguments...)
+	default:
+		server.logger.Warning(fmt.Sprintf("MESSAGE BUS: unknown message kind %d", message.Kind))
+	}
+}
+
+func (server *baseServer) onStorageChanged(args ...string) {
+	server.invalidateCache(args...)
+	server.publishToBus(BUS_INVALIDATE, "", nil, args...)
+}
@@ -0,0 +1,123 @@
+package server_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/security"
+	"github.com/xeronith/diamante/messaging"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server"
+	"github.com/xeronith/diamante/server/servertest"
+)
+
+func TestMessageBus(test *testing.T) {
+	port := rand.Intn(8999) + 20000
+	addresses := []string{
+		fmt.Sprintf("127.0.0.1:%d", port),
+		fmt.Sprintf("127.0.0.1:%d", port+1),
+		fmt.Sprintf("127.0.0.1:%d", port+2),
+	}
+
+	node := func(address, secret string, operations ...IOperation) *servertest.Harness {
+		test.Helper()
+
+		bus, err := messaging.NewTcpMessageBus(address, secret, addresses[:2]...)
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		test.Cleanup(func() { _ = bus.Close() })
+
+		harness := servertest.NewHarness(test, operations...)
+		harness.SetMessageBus(bus)
+		return harness
+	}
+
+	counting := &countingOperation{}
+	sender, receiver := node(addresses[0], "secret"), node(addresses[1], "secret", counting)
+	intruder := node(addresses[2], "guess")
+	receiver.SetRole(410, ANONYMOUS)
+
+	actor := receiver.Connect(receiver.NewIdentity(1, ANONYMOUS))
+	message := func(push IOperationResult) string {
+		test.Helper()
+
+		output := &protobuf.ServerError{}
+		if err := receiver.Decode(push, output); err != nil {
+			test.Fatal(err)
+		}
+
+		return output.Message
+	}
+
+	test.Run("broadcast", func(test *testing.T) {
+		actor.Reset()
+		if err := sender.Broadcast(101, &protobuf.ServerError{Message: "broadcast"}); err != nil {
+			test.Fatal(err)
+		}
+
+		pushes := actor.AwaitPushes(1)
+		if len(pushes) != 1 || pushes[0].Type() != 101 || message(pushes[0]) != "broadcast" {
+			test.Fatal(pushes)
+		}
+	})
+
+	test.Run("push", func(test *testing.T) {
+		actor.Reset()
+		for _, token := range []string{"unknown", actor.Token()} {
+			if err := sender.PushToken(token, messaging.NewPushMessage(101, &protobuf.ServerError{Message: token})); err != nil {
+				test.Fatal(err)
+			}
+		}
+
+		pushes := actor.AwaitPushes(1)
+		if len(pushes) != 1 || message(pushes[0]) != actor.Token() {
+			test.Fatal(pushes)
+		}
+	})
+
+	test.Run("invalidation", func(test *testing.T) {
+		call := func(actor *servertest.Actor) IOperationResult {
+			test.Helper()
+
+			result := receiver.Call(actor, 410, &protobuf.ServerError{})
+			if result.Status() != server.OK {
+				test.Fatal(result.Status())
+			}
+
+			return result
+		}
+
+		signature := call(receiver.PassiveActor(nil)).Signature()
+		if message(call(receiver.SignedActor(nil, signature))) != "1" {
+			test.Fatal("not cached")
+		}
+
+		sender.OnStorageUpdated()("UPDATE users SET name = 'user' WHERE id = 1")
+
+		deadline := time.Now().Add(servertest.AWAIT_TIMEOUT)
+		for message(call(receiver.SignedActor(nil, signature))) == "1" {
+			if time.Now().After(deadline) {
+				test.Fatal("not invalidated")
+			}
+
+			time.Sleep(time.Millisecond * 10)
+		}
+	})
+
+	test.Run("handshake", func(test *testing.T) {
+		actor.Reset()
+		if err := intruder.Broadcast(101, &protobuf.ServerError{Message: "intruder"}); err != nil {
+			test.Fatal(err)
+		}
+
+		time.Sleep(time.Millisecond * 500)
+		if pushes := actor.Pushes(); len(pushes) != 0 {
+			test.Fatal(message(pushes[0]))
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/caching"
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/server"
+	"github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/utility"
+)
+
+func (server *baseServer) Cache() IResultCache {
+	return server.cache
+}
+
+// UNTAGGED is the tag of the results cached without tags of their own. They
+// may depend on any table, so every storage update evicts them.
+const UNTAGGED = "*"
+
+// cacheLookup answers the request from the cache only when the client opts
+// in by sending the signature of the result it holds, such as the
+// X-Request-Signature header of http requests. Others always run the operation.
+func (server *baseServer) cacheLookup(pipeline IPipeline) (IOperationResult, bool) {
+	key := pipeline.RequestHash()
+	if key == "" || pipeline.Signature() == "" {
+		return nil, false
+	}
+
+	cached, exists := server.cache.Get(key)
+	if !exists || !pipeline.IsAcceptable(cached) {
+		return nil, false
+	}
+
+	// The cached result belongs to an earlier request; it is re-issued
+	// under the current request id so that socket clients can correlate it.
+	result := operation.CreateOperationResult(pipeline.RequestId(), cached.Status(), cached.Type(), cached.Payload(), pipeline, 0)
+	return result.UpdateStat(true, atomic.LoadInt64(&server.cacheMiss), atomic.AddInt64(&server.cacheHit, 1)), true
+}
+
+func (server *baseServer) cacheStore(pipeline IPipeline, result IOperationResult) {
+	operation := pipeline.Operation()
+	if operation == nil || !operation.IsCacheable() {
+		return
+	}
+
+	var ttl time.Duration
+	tags := make([]string, 0)
+	if cacheable, ok := operation.(ICacheableOperation); ok {
+		ttl, tags = cacheable.CacheTTL(), cacheable.CacheTags()
+	}
+
+	if len(tags) == 0 {
+		tags = []string{UNTAGGED}
+	}
+
+	server.cache.Put(pipeline.RequestHash(), result, ttl, tags...)
+}
+
+// invalidateCache evicts the results affected by a storage update, along with
+// the untagged ones. Each argument is either a sql command, whose written
+// tables are used as tags, or a tag by itself. Anything that cannot be
+// narrowed down clears the cache.
+func (server *baseServer) invalidateCache(args ...string) {
+	if len(args) == 0 {
+		server.cache.Clear()
+		return
+	}
+
+	tags := []string{UNTAGGED}
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		if tables := utility.ExtractTableNames(arg); len(tables) > 0 {
+			tags = append(tags, tables...)
+		} else if arg == "" || strings.ContainsAny(arg, " \t\r\n") {
+			server.cache.Clear()
+			return
+		} else {
+			tags = append(tags, arg)
+		}
+	}
+
+	server.cache.Invalidate(tags...)
+}
@@ -0,0 +1,124 @@
+package server_test
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/service"
+	. "github.com/xeronith/diamante/contracts/system"
+	"github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server"
+	"github.com/xeronith/diamante/server/servertest"
+)
+
+type countingOperation struct {
+	operation.Operation
+	executions int64
+}
+
+func (operation *countingOperation) Tag() string              { return "COUNT" }
+func (operation *countingOperation) Id() (ID, ID)             { return 410, 411 }
+func (operation *countingOperation) InputContainer() Pointer  { return &protobuf.ServerError{} }
+func (operation *countingOperation) OutputContainer() Pointer { return &protobuf.ServerError{} }
+func (operation *countingOperation) Execute(_ IContext, _ Pointer) (Pointer, error) {
+	return &protobuf.ServerError{Message: fmt.Sprintf("%d", atomic.AddInt64(&operation.executions, 1))}, nil
+}
+
+type versionedOperation struct {
+	operation.Operation
+	minimum    int32
+	maximum    int32
+	executions int64
+}
+
+func (operation *versionedOperation) Tag() string              { return "VERSIONED" }
+func (operation *versionedOperation) Id() (ID, ID)             { return 400, 401 }
+func (operation *versionedOperation) InputContainer() Pointer  { return &protobuf.ServerError{} }
+func (operation *versionedOperation) OutputContainer() Pointer { return &protobuf.ServerError{} }
+func (operation *versionedOperation) ApiVersions() (int32, int32) {
+	return operation.minimum, operation.maximum
+}
+func (operation *versionedOperation) Deprecation() (bool, time.Time) { return false, time.Time{} }
+func (operation *versionedOperation) Execute(_ IContext, _ Pointer) (Pointer, error) {
+	atomic.AddInt64(&operation.executions, 1)
+	return &protobuf.ServerError{Message: fmt.Sprintf("v%d", operation.minimum)}, nil
+}
+
+func TestCache(test *testing.T) {
+	counting := &countingOperation{}
+	harness := servertest.NewHarness(test, counting)
+	harness.SetRole(410, ANONYMOUS)
+
+	call := func(actor *servertest.Actor, expected string) IOperationResult {
+		test.Helper()
+
+		output := &protobuf.ServerError{}
+		result := harness.Call(actor, 410, &protobuf.ServerError{})
+		if result.Status() != server.OK || harness.Decode(result, output) != nil || output.Message != expected {
+			test.Fatal(result.Status(), output.Message)
+		}
+
+		return result
+	}
+
+	// Repeated requests run the operation unless the client opts in.
+	call(harness.PassiveActor(nil), "1")
+	result := call(harness.PassiveActor(nil), "2")
+
+	// A client holding the result is answered from the cache.
+	if call(harness.SignedActor(nil, result.Signature()), "2").Signature() != result.Signature() {
+		test.Fatal("cached result signed differently")
+	}
+
+	// Results cached without tags do not survive a storage update.
+	harness.OnStorageUpdated()("UPDATE users SET name = 'user' WHERE id = 1")
+	call(harness.SignedActor(nil, result.Signature()), "3")
+}
+
+func TestCache_ApiVersion(test *testing.T) {
+	first, second := &versionedOperation{minimum: 1, maximum: 1}, &versionedOperation{minimum: 2}
+	harness := servertest.NewHarness(test, first, second)
+	harness.SetRole(400, ANONYMOUS)
+
+	call := func(actor *servertest.Actor, apiVersion int32) IOperationResult {
+		test.Helper()
+
+		request := operation.CreateOperationRequest(1, 400, "", 0, apiVersion, "", nil)
+		if err := request.Load(&protobuf.ServerError{}, actor.Serializer()); err != nil {
+			test.Fatal(err)
+		}
+
+		data, err := actor.Serializer().Serialize(request.Container())
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		output := &protobuf.ServerError{}
+		result := harness.OnData(actor, data)
+		if result.Status() != server.OK || harness.Decode(result, output) != nil || output.Message != fmt.Sprintf("v%d", apiVersion) {
+			test.Fatal(apiVersion, result.Status(), output.Message)
+		}
+
+		return result
+	}
+
+	// The same request is answered by each version separately and then from
+	// the cache of its own version.
+	signatures := make(map[int32]string)
+	for _, apiVersion := range []int32{1, 2} {
+		signatures[apiVersion] = call(harness.PassiveActor(nil), apiVersion).Signature()
+	}
+
+	for _, apiVersion := range []int32{2, 1} {
+		call(harness.SignedActor(nil, signatures[apiVersion]), apiVersion)
+	}
+
+	if first.executions != 1 || second.executions != 1 {
+		test.Fatal(first.executions, second.executions)
+	}
+}
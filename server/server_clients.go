@@ -0,0 +1,74 @@
+package server
+
+import (
+	"strconv"
+
+	. "github.com/xeronith/diamante/contracts/server"
+)
+
+func (server *baseServer) SetClientMinimumVersion(clientName string, version int32) {
+	if version <= 0 {
+		server.clientMinimumVersions.Remove(clientName)
+		return
+	}
+
+	server.clientMinimumVersions.Put(clientName, version)
+}
+
+func (server *baseServer) ResolveClientMinimumVersion(clientName string) int32 {
+	if server.clientMinimumVersions.Contains(clientName) {
+		return server.clientMinimumVersions.Get(clientName)
+	}
+
+	return 0
+}
+
+// isClientSupported rejects the clients older than the minimum version set for
+// their name. Requests with no client name are accepted unless anonymous
+// clients are rejected in the configuration.
+func (server *baseServer) isClientSupported(pipeline IPipeline) bool {
+	if pipeline.IsSystemCall() {
+		return true
+	}
+
+	if pipeline.ClientName() == "" {
+		return !server.Configuration().GetServerConfiguration().GetClientsConfiguration().IsAnonymousRejected()
+	}
+
+	return pipeline.ClientVersion() >= server.ResolveClientMinimumVersion(pipeline.ClientName())
+}
+
+// clientSystemCall handles:
+//
+//	client <name> latest <version>
+//	client <name> minimum <version|off>
+func (server *baseServer) clientSystemCall(args []string) error {
+	if len(args) < 4 || args[1] == "" {
+		return INVALID_PARAMETERS
+	}
+
+	version := int64(0)
+	if args[3] != "off" {
+		value, err := strconv.ParseInt(args[3], 10, 32)
+		if err != nil || value < 1 {
+			return INVALID_PARAMETERS
+		}
+
+		version = value
+	}
+
+	switch args[2] {
+	case "latest":
+		if version == 0 {
+			server.clientRegistry.Remove(args[1])
+		} else {
+			server.RegisterClientVersion(args[1], int32(version))
+		}
+	case "minimum":
+		server.SetClientMinimumVersion(args[1], int32(version))
+	default:
+		return INVALID_PARAMETERS
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package server_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/server"
+	. "github.com/xeronith/diamante/contracts/service"
+	. "github.com/xeronith/diamante/contracts/system"
+	"github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server"
+	"github.com/xeronith/diamante/server/servertest"
+	"github.com/xeronith/diamante/settings"
+)
+
+// systemCallOperation runs the system call in the message of its input.
+type systemCallOperation struct {
+	operation.Operation
+}
+
+func (operation *systemCallOperation) Tag() string { return "SYSTEM_CALL" }
+func (operation *systemCallOperation) Id() (ID, ID) {
+	return SYSTEM_CALL_REQUEST, SYSTEM_CALL_REQUEST + 1
+}
+func (operation *systemCallOperation) InputContainer() Pointer  { return &protobuf.ServerError{} }
+func (operation *systemCallOperation) OutputContainer() Pointer { return &protobuf.ServerError{} }
+func (operation *systemCallOperation) Execute(context IContext, payload Pointer) (Pointer, error) {
+	if err := context.SystemCall(strings.Fields(payload.(*protobuf.ServerError).Message)); err != nil {
+		return nil, err
+	}
+
+	return &protobuf.ServerError{}, nil
+}
+
+func TestClients(test *testing.T) {
+	harness := servertest.NewHarness(test, &echoOperation{}, &systemCallOperation{})
+	harness.SetRole(100, ANONYMOUS)
+	harness.SetRole(SYSTEM_CALL_REQUEST, ANONYMOUS)
+
+	call := func(clientName string, clientVersion int32, opcode uint64, message string) IOperationResult {
+		test.Helper()
+
+		actor := harness.PassiveActor(nil)
+		request := operation.CreateOperationRequest(1, opcode, clientName, clientVersion, 0, "", nil)
+		if err := request.Load(&protobuf.ServerError{Message: message}, actor.Serializer()); err != nil {
+			test.Fatal(err)
+		}
+
+		data, err := actor.Serializer().Serialize(request.Container())
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		return harness.OnData(actor, data)
+	}
+
+	systemCall := func(command string) {
+		test.Helper()
+
+		if result := call("", 0, SYSTEM_CALL_REQUEST, command); result.Status() != server.OK {
+			test.Fatal(command, result.Status())
+		}
+	}
+
+	for _, command := range []string{"client", "client app", "client app minimum", "client app minimum 0", "client app oldest 1", "client  minimum 1"} {
+		if result := call("", 0, SYSTEM_CALL_REQUEST, command); result.Status() == server.OK {
+			test.Fatal(command)
+		}
+	}
+
+	test.Run("minimum", func(test *testing.T) {
+		systemCall("client app minimum 2")
+
+		if result := call("app", 1, 100, "echo"); result.Status() != server.UpgradeRequired {
+			test.Fatal(result.Status())
+		}
+
+		for _, version := range []int32{2, 3} {
+			if result := call("app", version, 100, "echo"); result.Status() != server.OK {
+				test.Fatal(version, result.Status())
+			}
+		}
+
+		// Other clients and anonymous ones are not affected.
+		if result := call("other", 1, 100, "echo"); result.Status() != server.OK {
+			test.Fatal(result.Status())
+		}
+
+		if result := call("", 0, 100, "echo"); result.Status() != server.OK {
+			test.Fatal(result.Status())
+		}
+
+		systemCall("client app minimum off")
+		if result := call("app", 1, 100, "echo"); result.Status() != server.OK {
+			test.Fatal(result.Status())
+		}
+	})
+
+	test.Run("latest", func(test *testing.T) {
+		if result := call("app", 1, 100, "echo"); result.ClientLatestVersion() != 0 {
+			test.Fatal(result.ClientLatestVersion())
+		}
+
+		systemCall("client app latest 3")
+		if result := call("app", 1, 100, "echo"); result.Status() != server.OK || result.ClientLatestVersion() != 3 {
+			test.Fatal(result.Status(), result.ClientLatestVersion())
+		}
+
+		if result := call("other", 1, 100, "echo"); result.ClientLatestVersion() != 0 {
+			test.Fatal(result.ClientLatestVersion())
+		}
+
+		systemCall("client app latest off")
+		if result := call("app", 1, 100, "echo"); result.ClientLatestVersion() != 0 {
+			test.Fatal(result.ClientLatestVersion())
+		}
+	})
+
+	test.Run("anonymous", func(test *testing.T) {
+		harness.Configuration().GetServerConfiguration().GetClientsConfiguration().(*settings.Clients).RejectAnonymous = true
+		defer func() {
+			harness.Configuration().GetServerConfiguration().GetClientsConfiguration().(*settings.Clients).RejectAnonymous = false
+		}()
+
+		if result := call("", 0, 100, "echo"); result.Status() != server.UpgradeRequired {
+			test.Fatal(result.Status())
+		}
+
+		if result := call("app", 1, 100, "echo"); result.Status() != server.OK {
+			test.Fatal(result.Status())
+		}
+
+		// System calls are never turned away.
+		systemCall("client app minimum off")
+	})
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"errors"
+	"time"
 
 	. "github.com/xeronith/diamante/contracts/operation"
 	. "github.com/xeronith/diamante/operation"
@@ -18,6 +19,20 @@ var (
 	INTERNAL_SERVER_ERROR                         = errors.New("internal_server_error")
 	UNAUTHORIZED                                  = errors.New("unauthorized")
 	BAD_REQUEST                                   = errors.New("bad_request")
+	SERVER_SHUTTING_DOWN                          = errors.New("server_shutting_down")
+	GATEWAY_TIMEOUT                               = errors.New("gateway_timeout")
+	TOO_MANY_REQUESTS                             = errors.New("too_many_requests")
+	STREAM_COMPLETED                              = errors.New("stream_completed")
+	NESTED_BATCH_REQUEST                          = errors.New("nested_batch_request")
+	STREAMING_IN_BATCH_REQUEST                    = errors.New("streaming_in_batch_request")
+	BATCH_REQUEST_TOO_LARGE                       = errors.New("batch_request_too_large")
+	BATCH_TOKEN_MISMATCH                          = errors.New("batch_token_mismatch")
+	UNSUPPORTED_API_VERSION                       = errors.New("unsupported_api_version")
+	API_VERSION_SUNSET                            = errors.New("api_version_sunset")
+	CLIENT_UPGRADE_REQUIRED                       = errors.New("client_upgrade_required")
+	INVALID_TOPIC                                 = errors.New("invalid_topic")
+	SUBSCRIPTION_NOT_SUPPORTED                    = errors.New("subscription_not_supported")
+	IDLE_TIMEOUT                                  = errors.New("idle_timeout")
 )
 
 func (pipeline *pipeline) ServiceUnavailable(errors ...error) IOperationResult {
@@ -65,13 +80,60 @@ func (pipeline *pipeline) BadRequest(errors ...error) IOperationResult {
 	return pipeline.serverError(BadRequest, err)
 }
 
+func (pipeline *pipeline) Gone(errors ...error) IOperationResult {
+	err := API_VERSION_SUNSET
+	if len(errors) > 0 {
+		err = errors[0]
+	}
+
+	return pipeline.serverError(Gone, err)
+}
+
+func (pipeline *pipeline) UpgradeRequired(errors ...error) IOperationResult {
+	err := CLIENT_UPGRADE_REQUIRED
+	if len(errors) > 0 {
+		err = errors[0]
+	}
+
+	return pipeline.serverError(UpgradeRequired, err)
+}
+
+func (pipeline *pipeline) GatewayTimeout(errors ...error) IOperationResult {
+	err := GATEWAY_TIMEOUT
+	if len(errors) > 0 {
+		err = errors[0]
+	}
+
+	return pipeline.serverError(GatewayTimeout, err)
+}
+
+func (pipeline *pipeline) TooManyRequests(retryAfter time.Duration, errors ...error) IOperationResult {
+	err := TOO_MANY_REQUESTS
+	if len(errors) > 0 {
+		err = errors[0]
+	}
+
+	serverError := pipeline.createServerError(err)
+	serverError.RetryAfter = retryAfter.Milliseconds()
+
+	return pipeline.createServerErrorResult(TooManyRequests, serverError)
+}
+
 func (pipeline *pipeline) serverError(status int32, err error) IOperationResult {
+	return pipeline.createServerErrorResult(status, pipeline.createServerError(err))
+}
+
+func (pipeline *pipeline) createServerError(err error) *ServerError {
 	serverError := &ServerError{}
 	if err != nil {
 		serverError.Message = err.Error()
 		serverError.Description = pipeline.localizer.Get(serverError.Message)
 	}
 
+	return serverError
+}
+
+func (pipeline *pipeline) createServerErrorResult(status int32, serverError *ServerError) IOperationResult {
 	var (
 		payload []byte
 		result  IOperationResult
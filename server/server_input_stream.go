@@ -1,27 +1,69 @@
 package server
 
 import (
+	"sync/atomic"
+	"time"
+
 	. "github.com/xeronith/diamante/contracts/actor"
 	. "github.com/xeronith/diamante/contracts/analytics"
 	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/server"
 )
 
 func (server *baseServer) OnData(actor IActor, data []byte) IOperationResult {
+	timestamp := time.Now()
+	request, result := server.decode(actor, data)
+	if result == nil {
+		result = server.handleRequest(actor, request)
+	}
+
+	server.recordTraffic(actor, data, result, timestamp)
+	return result
+}
+
+func (server *baseServer) decode(actor IActor, data []byte) (IOperationRequest, IOperationResult) {
+	timestamp := time.Now()
 	request := server.operationRequestPool.Get().(IOperationRequest)
 	if err := actor.Serializer().Deserialize(data, request.Container()); err != nil {
 		pipeline := NewPipeline(server, actor, request)
-		return pipeline.InternalServerError(INPUT_STREAM_DESERIALIZATION_FAILURE)
+		result := pipeline.InternalServerError(INPUT_STREAM_DESERIALIZATION_FAILURE)
+		// Frames that cannot be decoded never reach handleRequest, so
+		// they are logged here.
+		server.logRequest(pipeline, request, result, timestamp)
+		return nil, result
 	}
 
+	return request, nil
+}
+
+func (server *baseServer) handleRequest(actor IActor, request IOperationRequest) IOperationResult {
+	atomic.AddInt64(&server.pendingPipelines, 1)
+	defer atomic.AddInt64(&server.pendingPipelines, -1)
+
 	pipeline := NewPipeline(server, actor, request)
 	// r: request_initiated, f: request_finalized, op: operation, id: request_id
 	fields := Fields{"op": int64(pipeline.Opcode()), "id": int64(pipeline.RequestId())}
 	/* //////// */ server.measurement("operations", Tags{"type": "r"}, fields)
 	defer func() { server.measurement("operations", Tags{"type": "f"}, fields) }()
 
+	timestamp := time.Now()
+	result := server.processPipeline(pipeline, request)
+	server.logRequest(pipeline, request, result, timestamp)
+	return result
+}
+
+func (server *baseServer) processPipeline(pipeline IPipeline, request IOperationRequest) IOperationResult {
+	if server.IsShuttingDown() {
+		return pipeline.ServiceUnavailable(SERVER_SHUTTING_DOWN)
+	}
+
 	if pipeline.IsFrozen() {
 		return pipeline.ServiceUnavailable()
 	}
 
+	if !server.isClientSupported(pipeline) {
+		return pipeline.UpgradeRequired()
+	}
+
 	return server.OnOperationRequest(pipeline, request)
 }
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"errors"
+
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/server"
+)
+
+type interceptor struct {
+	before InterceptorBeforeFunc
+	after  InterceptorAfterFunc
+}
+
+func NewInterceptor(before InterceptorBeforeFunc, after InterceptorAfterFunc) IInterceptor {
+	return &interceptor{
+		before: before,
+		after:  after,
+	}
+}
+
+func (interceptor *interceptor) Before(pipeline IPipeline, request IOperationRequest) IOperationResult {
+	if interceptor.before == nil {
+		return nil
+	}
+
+	return interceptor.before(pipeline, request)
+}
+
+func (interceptor *interceptor) After(pipeline IPipeline, request IOperationRequest, result IOperationResult) IOperationResult {
+	if interceptor.after == nil {
+		return nil
+	}
+
+	return interceptor.after(pipeline, request, result)
+}
+
+func (server *baseServer) RegisterInterceptor(interceptor IInterceptor) error {
+	if interceptor == nil {
+		return errors.New("nil interceptor")
+	}
+
+	if server.isRunning() {
+		return errors.New("not allowed to register interceptors when server is running")
+	}
+
+	server.interceptors = append(server.interceptors, interceptor)
+	return nil
+}
+
+func (server *baseServer) RegisterInterceptors(interceptors ...IInterceptor) error {
+	for _, interceptor := range interceptors {
+		if err := server.RegisterInterceptor(interceptor); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (server *baseServer) intercept(pipeline IPipeline, request IOperationRequest, index int) IOperationResult {
+	if index >= len(server.interceptors) {
+		return server.processOperationRequest(pipeline, request)
+	}
+
+	interceptor := server.interceptors[index]
+
+	result := interceptor.Before(pipeline, request)
+	if result == nil {
+		result = server.intercept(pipeline, request, index+1)
+	}
+
+	if replacement := interceptor.After(pipeline, request, result); replacement != nil {
+		return replacement
+	}
+
+	return result
+}
@@ -0,0 +1,67 @@
+package server_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/server"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server"
+	"github.com/xeronith/diamante/server/servertest"
+)
+
+func TestInterceptors(test *testing.T) {
+	harness := servertest.NewHarness(test, &echoOperation{})
+	harness.SetRole(100, ANONYMOUS)
+
+	calls := make([]string, 0)
+	interceptor := func(name string) IInterceptor {
+		return server.NewInterceptor(
+			func(pipeline IPipeline, request IOperationRequest) IOperationResult {
+				calls = append(calls, "before "+name)
+				if name == "quota" && request.Id() > 1 {
+					return pipeline.TooManyRequests(time.Minute, errors.New("quota_exceeded"))
+				}
+
+				return nil
+			},
+			func(IPipeline, IOperationRequest, IOperationResult) IOperationResult {
+				calls = append(calls, "after "+name)
+				return nil
+			},
+		)
+	}
+
+	if err := harness.RegisterInterceptors(interceptor("audit"), interceptor("quota")); err != nil {
+		test.Fatal(err)
+	}
+
+	actor := harness.PassiveActor(nil)
+	output := &protobuf.ServerError{}
+	if err := harness.Invoke(actor, 100, &protobuf.ServerError{Message: "hello"}, output); err != nil || output.Message != "hello" {
+		test.Fatal(err, output.Message)
+	}
+
+	expected := []string{"before audit", "before quota", "after quota", "after audit"}
+	if len(calls) != len(expected) {
+		test.Fatal(calls)
+	}
+
+	for index := range expected {
+		if calls[index] != expected[index] {
+			test.Fatal(calls)
+		}
+	}
+
+	calls = calls[:0]
+	if result := harness.Call(actor, 100, &protobuf.ServerError{}); result.Status() != server.TooManyRequests {
+		test.Fatal(result.Status())
+	}
+
+	if len(calls) != 4 || calls[1] != "before quota" || calls[2] != "after quota" {
+		test.Fatal(calls)
+	}
+}
@@ -5,8 +5,10 @@ import (
 
 	. "github.com/xeronith/diamante/contracts/actor"
 	. "github.com/xeronith/diamante/contracts/messaging"
+	. "github.com/xeronith/diamante/contracts/operation"
 	. "github.com/xeronith/diamante/contracts/system"
 	. "github.com/xeronith/diamante/operation"
+	. "github.com/xeronith/diamante/protobuf"
 	"github.com/xeronith/diamante/utility/reflection"
 )
 
@@ -16,30 +18,17 @@ func (server *baseServer) Broadcast(resultType uint64, payload Pointer) error {
 		return errors.New("broadcast_failure: non_pointer_payload")
 	}
 
-	var (
-		err               error
-		serializedPayload []byte
-	)
-
-	return server.connectedActors.ForEachParallelWithInitialization(
-		func(count int) error {
-			// fmt.Printf("Broadcast to %d clients\n", count)
-			serializer := server.serializers["application/octet-stream"]
-			serializedPayload, err = serializer.Serialize(payload)
-			if err != nil {
-				server.logger.Error(err)
-				return err
-			}
-
-			operationResult := CreateOperationResult(BROADCAST, OK, resultType, serializedPayload, NO_PIPELINE_INFO, 0)
+	serializedPayload, err := server.serializeBroadcast(resultType, payload)
+	if err != nil {
+		return err
+	}
 
-			if serializedPayload, err = serializer.Serialize(operationResult.Container()); err != nil {
-				server.logger.Error(err)
-				return err
-			}
+	server.publishToBus(BUS_BROADCAST, "", serializedPayload)
+	return server.broadcast(serializedPayload)
+}
 
-			return nil
-		},
+func (server *baseServer) broadcast(serializedPayload []byte) error {
+	return server.connectedActors.ForEachParallelWithInitialization(nil,
 		func(object Pointer) {
 			actor := object.(IActor)
 			if actor.Writer().IsOpen() {
@@ -60,29 +49,22 @@ func (server *baseServer) BroadcastSpecific(resultType uint64, payloads map[stri
 	}
 
 	data := make(map[string][]byte)
-	return server.connectedActors.ForEachParallelWithInitialization(
-		func(count int) error {
-			serializer := server.serializers["application/octet-stream"]
-			for token, payload := range payloads {
-				// TODO: What if payload is nil?
-				serializedPayload, err := serializer.Serialize(payload)
-				if err != nil {
-					server.logger.Error(err)
-					return err
-				}
+	for token, payload := range payloads {
+		// TODO: What if payload is nil?
+		serializedPayload, err := server.serializeBroadcast(resultType, payload)
+		if err != nil {
+			return err
+		}
 
-				operationResult := CreateOperationResult(BROADCAST, OK, resultType, serializedPayload, NO_PIPELINE_INFO, 0)
-				serializedOperationResult, err := serializer.Serialize(operationResult.Container())
-				if err != nil {
-					server.logger.Error(err)
-					return err
-				}
+		data[token] = serializedPayload
+	}
 
-				data[token] = serializedOperationResult
-			}
+	for token, serializedPayload := range data {
+		server.publishToBus(BUS_PUSH, token, serializedPayload)
+	}
 
-			return nil
-		}, func(object Pointer) {
+	return server.connectedActors.ForEachParallelWithInitialization(nil,
+		func(object Pointer) {
 			actor := object.(IActor)
 			if actor.Writer().IsOpen() {
 				if _, exists := data[actor.Token()]; exists {
@@ -103,29 +85,100 @@ func (server *baseServer) Push(actor IActor, message IPushMessage) error {
 		return errors.New("broadcast_failure: non_pointer_payload")
 	}
 
-	var (
-		err               error
-		serializedPayload []byte
-	)
+	serializedPayload, err := server.serializeBroadcast(resultType, payload)
+	if err != nil {
+		return err
+	}
+
+	if actor.Writer().IsOpen() {
+		actor.Writer().WriteBytes(serializedPayload)
+	} else {
+		server.OnSocketDisconnected(actor)
+	}
+
+	return nil
+}
+
+// PushToken sends the message to every connection of the token, on this
+// node and, when a message bus is set, on the other nodes of the cluster.
+func (server *baseServer) PushToken(token string, message IPushMessage) error {
+	payload := message.GetPayload()
+	if !reflection.IsPointer(payload) {
+		return errors.New("push_failure: non_pointer_payload")
+	}
 
+	serializedPayload, err := server.serializeBroadcast(message.GetType(), payload)
+	if err != nil {
+		return err
+	}
+
+	server.publishToBus(BUS_PUSH, token, serializedPayload)
+	server.pushToken(token, serializedPayload)
+	return nil
+}
+
+func (server *baseServer) pushToken(token string, serializedPayload []byte) {
+	if token == "" {
+		return
+	}
+
+	server.connectedActors.ForEachParallel(func(object Pointer, _ ISystemObject) {
+		actor := object.(IActor)
+		if actor.Token() != token {
+			return
+		}
+
+		if actor.Writer().IsOpen() {
+			actor.Writer().WriteBytes(serializedPayload)
+		} else {
+			server.OnSocketDisconnected(actor)
+		}
+	})
+}
+
+func (server *baseServer) serializeBroadcast(resultType uint64, payload Pointer) ([]byte, error) {
 	serializer := server.serializers["application/octet-stream"]
-	serializedPayload, err = serializer.Serialize(payload)
+	serializedPayload, err := serializer.Serialize(payload)
 	if err != nil {
 		server.logger.Error(err)
-		return err
+		return nil, err
 	}
 
 	operationResult := CreateOperationResult(BROADCAST, OK, resultType, serializedPayload, NO_PIPELINE_INFO, 0)
 	if serializedPayload, err = serializer.Serialize(operationResult.Container()); err != nil {
 		server.logger.Error(err)
-		return err
+		return nil, err
 	}
 
-	if actor.Writer().IsOpen() {
-		actor.Writer().WriteBytes(serializedPayload)
-	} else {
-		server.OnSocketDisconnected(actor)
+	return serializedPayload, nil
+}
+
+func (server *baseServer) disconnectAll(reason error) {
+	operationResult, err := server.createDisconnectResult(ServiceUnavailable, reason)
+	if err != nil {
+		return
 	}
 
-	return nil
+	server.connectedActors.ForEachParallel(func(object Pointer, _ ISystemObject) {
+		actor := object.(IActor)
+		if actor.Writer().IsOpen() {
+			actor.Disconnect(operationResult)
+		}
+	})
+}
+
+func (server *baseServer) createDisconnectResult(status int32, reason error) (IOperationResult, error) {
+	serializer := server.serializers["application/octet-stream"]
+	serverError := &ServerError{
+		Message:     reason.Error(),
+		Description: server.localizer.Get(reason.Error()),
+	}
+
+	payload, err := serializer.Serialize(serverError)
+	if err != nil {
+		server.logger.Error(err)
+		return nil, err
+	}
+
+	return CreateOperationResult(BROADCAST, status, ERROR, payload, NO_PIPELINE_INFO, 0), nil
 }
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+
+	. "github.com/xeronith/diamante/contracts/actor"
+	. "github.com/xeronith/diamante/contracts/presence"
+	"github.com/xeronith/diamante/logging"
+	"github.com/xeronith/diamante/utility"
+	. "github.com/xeronith/diamante/utility/collections"
+)
+
+// presence keeps track of the persistent connections of this node by token
+// and by identity. It also keeps the token to actor map that Actor, Session
+// and SetSession read, pointing every token to one of its live connections.
+type presence struct {
+	sync.RWMutex
+	actors      IStringMap
+	tokens      map[IActor]string
+	identities  map[IActor]int64
+	byToken     map[string]map[IActor]struct{}
+	connections map[int64]map[IActor]struct{}
+	listeners   map[string]func(Event)
+}
+
+func newPresence(actors IStringMap) *presence {
+	return &presence{
+		actors:      actors,
+		tokens:      make(map[IActor]string),
+		identities:  make(map[IActor]int64),
+		byToken:     make(map[string]map[IActor]struct{}),
+		connections: make(map[int64]map[IActor]struct{}),
+		listeners:   make(map[string]func(Event)),
+	}
+}
+
+func (presence *presence) IsOnline(identityId int64) bool {
+	presence.RLock()
+	defer presence.RUnlock()
+
+	return len(presence.connections[identityId]) > 0
+}
+
+func (presence *presence) Connections(identityId int64) []Connection {
+	presence.RLock()
+	defer presence.RUnlock()
+
+	connections := make([]Connection, 0, len(presence.connections[identityId]))
+	for actor := range presence.connections[identityId] {
+		connections = append(connections, connection(actor))
+	}
+
+	return connections
+}
+
+// LastActivity returns the most recent activity of all the connections of
+// the identity, or zero when the identity is offline.
+func (presence *presence) LastActivity(identityId int64) int64 {
+	presence.RLock()
+	defer presence.RUnlock()
+
+	var lastActivity int64
+	for actor := range presence.connections[identityId] {
+		if activity := actor.LastActivity(); activity > lastActivity {
+			lastActivity = activity
+		}
+	}
+
+	return lastActivity
+}
+
+func (presence *presence) OnlineIdentities() []int64 {
+	presence.RLock()
+	defer presence.RUnlock()
+
+	identities := make([]int64, 0, len(presence.connections))
+	for identityId := range presence.connections {
+		identities = append(identities, identityId)
+	}
+
+	return identities
+}
+
+// Subscribe registers a listener that is notified when an identity comes
+// online with its first connection or goes offline with its last one.
+func (presence *presence) Subscribe(listener func(Event)) string {
+	id := utility.GenerateUUID()
+
+	presence.Lock()
+	defer presence.Unlock()
+
+	presence.listeners[id] = listener
+	return id
+}
+
+func (presence *presence) Unsubscribe(id string) {
+	presence.Lock()
+	defer presence.Unlock()
+
+	delete(presence.listeners, id)
+}
+
+func (presence *presence) track(actor IActor) {
+	token := actor.Token()
+
+	var identityId int64
+	if identity := actor.Identity(); identity != nil {
+		identityId = identity.Id()
+	}
+
+	presence.RLock()
+	tracked := presence.isTracked(actor, token, identityId)
+	presence.RUnlock()
+
+	if tracked {
+		return
+	}
+
+	presence.Lock()
+	if presence.isTracked(actor, token, identityId) {
+		presence.Unlock()
+		return
+	}
+
+	events := presence.remove(actor)
+
+	presence.tokens[actor] = token
+	if token != "" {
+		if presence.byToken[token] == nil {
+			presence.byToken[token] = make(map[IActor]struct{})
+		}
+
+		presence.byToken[token][actor] = struct{}{}
+		presence.actors.Put(token, actor)
+	}
+
+	if identityId != 0 {
+		if presence.connections[identityId] == nil {
+			presence.connections[identityId] = make(map[IActor]struct{})
+		}
+
+		presence.identities[actor] = identityId
+		presence.connections[identityId][actor] = struct{}{}
+		if len(presence.connections[identityId]) == 1 {
+			events = append(events, Event{IdentityId: identityId, Online: true, Connection: connection(actor)})
+		}
+	}
+
+	presence.Unlock()
+
+	presence.emit(events)
+}
+
+func (presence *presence) isTracked(actor IActor, token string, identityId int64) bool {
+	previousToken, tracked := presence.tokens[actor]
+	return tracked && previousToken == token && presence.identities[actor] == identityId
+}
+
+func (presence *presence) untrack(actor IActor) {
+	presence.Lock()
+	events := presence.remove(actor)
+	presence.Unlock()
+
+	presence.emit(events)
+}
+
+func (presence *presence) remove(actor IActor) []Event {
+	events := make([]Event, 0)
+
+	if token, exists := presence.tokens[actor]; exists {
+		delete(presence.tokens, actor)
+		if actors, exists := presence.byToken[token]; exists {
+			delete(actors, actor)
+			if len(actors) == 0 {
+				delete(presence.byToken, token)
+				presence.actors.Remove(token)
+			} else if current, exists := presence.actors.Get(token); exists && current == actor {
+				for other := range actors {
+					presence.actors.Put(token, other)
+					break
+				}
+			}
+		}
+	}
+
+	if identityId, exists := presence.identities[actor]; exists {
+		delete(presence.identities, actor)
+		if connections, exists := presence.connections[identityId]; exists {
+			delete(connections, actor)
+			if len(connections) == 0 {
+				delete(presence.connections, identityId)
+				events = append(events, Event{IdentityId: identityId, Online: false, Connection: connection(actor)})
+			}
+		}
+	}
+
+	return events
+}
+
+func (presence *presence) emit(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	presence.RLock()
+	listeners := make([]func(Event), 0, len(presence.listeners))
+	for _, listener := range presence.listeners {
+		listeners = append(listeners, listener)
+	}
+	presence.RUnlock()
+
+	for _, event := range events {
+		for _, listener := range listeners {
+			func() {
+				defer func() {
+					if reason := recover(); reason != nil {
+						logging.GetDefaultLogger().Panic(fmt.Sprintf("PRESENCE: %s", reason))
+					}
+				}()
+
+				listener(event)
+			}()
+		}
+	}
+}
+
+func connection(actor IActor) Connection {
+	return Connection{
+		Token:         actor.Token(),
+		RemoteAddress: actor.RemoteAddress(),
+		UserAgent:     actor.UserAgent(),
+		LastActivity:  actor.LastActivity(),
+	}
+}
+
+func (server *baseServer) Presence() IPresence {
+	return server.presence
+}
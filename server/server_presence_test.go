@@ -0,0 +1,154 @@
+package server_test
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	. "github.com/xeronith/diamante/contracts/actor"
+	. "github.com/xeronith/diamante/contracts/presence"
+	. "github.com/xeronith/diamante/contracts/security"
+	"github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server"
+	"github.com/xeronith/diamante/server/servertest"
+)
+
+func TestPresence(test *testing.T) {
+	harness := servertest.NewHarness(test, &echoOperation{})
+	harness.SetRole(100, USER)
+
+	var mutex sync.Mutex
+	events := make([]Event, 0)
+	subscription := harness.Presence().Subscribe(func(event Event) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		events = append(events, event)
+	})
+
+	received := func() []Event {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		received := events
+		events = make([]Event, 0)
+		return received
+	}
+
+	presence := harness.Presence()
+	identity, other := harness.NewIdentity(1, USER), harness.NewIdentity(2, USER)
+
+	test.Run("sockets", func(test *testing.T) {
+		first := harness.Connect(identity)
+		if events := received(); len(events) != 1 || !events[0].Online || events[0].IdentityId != 1 || events[0].Connection.Token != identity.Token() {
+			test.Fatal(events)
+		}
+
+		if !presence.IsOnline(1) || presence.IsOnline(2) || presence.LastActivity(1) == 0 {
+			test.Fatal(presence.IsOnline(1), presence.IsOnline(2), presence.LastActivity(1))
+		}
+
+		// Further sockets of an online identity do not change its presence.
+		second := harness.Connect(identity)
+		if events := received(); len(events) != 0 {
+			test.Fatal(events)
+		}
+
+		if connections := presence.Connections(1); len(connections) != 2 {
+			test.Fatal(connections)
+		}
+
+		harness.Disconnect(first)
+		if events := received(); len(events) != 0 || !presence.IsOnline(1) || len(presence.Connections(1)) != 1 {
+			test.Fatal(events, presence.Connections(1))
+		}
+
+		// The last socket to go takes the identity offline.
+		harness.Disconnect(second)
+		if events := received(); len(events) != 1 || events[0].Online || events[0].IdentityId != 1 {
+			test.Fatal(events)
+		}
+
+		if presence.IsOnline(1) || len(presence.Connections(1)) != 0 || presence.LastActivity(1) != 0 {
+			test.Fatal(presence.Connections(1), presence.LastActivity(1))
+		}
+	})
+
+	test.Run("directory", func(test *testing.T) {
+		first, second := harness.Connect(identity), harness.Connect(identity)
+		third := harness.Connect(other)
+		received()
+
+		identities := presence.OnlineIdentities()
+		sort.Slice(identities, func(i, j int) bool { return identities[i] < identities[j] })
+		if len(identities) != 2 || identities[0] != 1 || identities[1] != 2 {
+			test.Fatal(identities)
+		}
+
+		if err := harness.SetSession(identity.Token(), "session"); err != nil {
+			test.Fatal(err)
+		}
+
+		// The token keeps pointing to a live socket until the last one goes.
+		harness.Disconnect(first)
+		if actor, err := harness.Actor(identity.Token()); err != nil || actor != IActor(second) {
+			test.Fatal(actor, err)
+		}
+
+		harness.Disconnect(second)
+		if _, err := harness.Actor(identity.Token()); err == nil {
+			test.Fatal("disconnected actor found")
+		}
+
+		if _, err := harness.Session(identity.Token()); err == nil {
+			test.Fatal("session of a disconnected actor found")
+		}
+
+		if actor, err := harness.Actor(other.Token()); err != nil || actor != IActor(third) {
+			test.Fatal(actor, err)
+		}
+
+		harness.Disconnect(third)
+		received()
+	})
+
+	test.Run("authentication", func(test *testing.T) {
+		// Sockets opened anonymously come online once they authenticate,
+		// while request-response actors are never tracked.
+		actor := harness.Connect(nil)
+		passive := harness.PassiveActor(other)
+		if events := received(); len(events) != 0 {
+			test.Fatal(events)
+		}
+
+		request := operation.CreateOperationRequest(1, 100, "", 0, 0, identity.Token(), nil)
+		if err := request.Load(&protobuf.ServerError{}, actor.Serializer()); err != nil {
+			test.Fatal(err)
+		}
+
+		data, err := actor.Serializer().Serialize(request.Container())
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		if result := harness.OnData(actor, data); result.Status() != server.OK {
+			test.Fatal(result.Status())
+		}
+
+		if result := harness.Call(passive, 100, &protobuf.ServerError{}); result.Status() != server.OK {
+			test.Fatal(result.Status())
+		}
+
+		if events := received(); len(events) != 1 || !events[0].Online || events[0].IdentityId != 1 || presence.IsOnline(2) {
+			test.Fatal(events)
+		}
+
+		// Unsubscribed listeners are not notified.
+		presence.Unsubscribe(subscription)
+		harness.Disconnect(actor)
+		if events := received(); len(events) != 0 || presence.IsOnline(1) {
+			test.Fatal(events)
+		}
+	})
+}
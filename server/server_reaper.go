@@ -0,0 +1,61 @@
+package server
+
+import (
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/actor"
+	. "github.com/xeronith/diamante/contracts/analytics"
+	. "github.com/xeronith/diamante/contracts/system"
+)
+
+// scheduleReaper disconnects, on the server scheduler, the connections that
+// have gone without any activity for longer than the configured idle timeout.
+// Reaped actors are gone for good, even within their resumption grace period.
+func (server *baseServer) scheduleReaper() {
+	idleTimeout := server.configuration.GetServerConfiguration().GetWebSocketConfiguration().GetIdleTimeout()
+	if idleTimeout <= 0 {
+		return
+	}
+
+	interval := idleTimeout / 4
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	server.scheduler.SetInterval(func() {
+		server.reapIdleActors(idleTimeout)
+	}, interval)
+}
+
+func (server *baseServer) reapIdleActors(idleTimeout time.Duration) {
+	threshold := time.Now().Add(-idleTimeout).UnixNano()
+
+	idleActors := make([]IActor, 0)
+	server.connectedActors.ForEach(func(object Pointer, _ ISystemObject) {
+		if actor := object.(IActor); actor.LastActivity() < threshold {
+			idleActors = append(idleActors, actor)
+		}
+	})
+
+	if len(idleActors) == 0 {
+		return
+	}
+
+	operationResult, err := server.createDisconnectResult(RequestTimeout, IDLE_TIMEOUT)
+	if err != nil {
+		return
+	}
+
+	for _, actor := range idleActors {
+		// Forgetting the resume token first keeps the socket close that
+		// follows from suspending the actor.
+		server.resumption.remove(actor)
+		if actor.Writer().IsOpen() {
+			actor.Disconnect(operationResult)
+		}
+
+		server.OnSocketDisconnected(actor)
+	}
+
+	server.measurement("websocket", Tags{"type": "r", "reason": "idle"}, Fields{"value": len(idleActors)})
+}
@@ -0,0 +1,63 @@
+package server_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	. "github.com/xeronith/diamante/contracts/analytics"
+	. "github.com/xeronith/diamante/contracts/security"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server/servertest"
+	"github.com/xeronith/diamante/settings"
+)
+
+func TestReaper(test *testing.T) {
+	harness := servertest.NewHarness(test, &echoOperation{})
+	harness.SetRole(100, ANONYMOUS)
+
+	webSocketConfiguration := harness.Configuration().GetServerConfiguration().GetWebSocketConfiguration().(*settings.WebSocket)
+	webSocketConfiguration.IdleTimeout = "100ms"
+	webSocketConfiguration.HeartbeatInterval = "20ms"
+	harness.Serve()
+
+	// The socket sends nothing but the pongs that answer the heartbeats.
+	connection, _, err := websocket.DefaultDialer.Dial(harness.ActiveEndpoint(), nil)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	defer connection.Close()
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := connection.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	idle, busy := harness.Connect(nil), harness.Connect(nil)
+	for deadline := time.Now().Add(time.Millisecond * 150); time.Now().Before(deadline); {
+		harness.Call(busy, 100, &protobuf.ServerError{})
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	harness.Clock().Advance(time.Second)
+
+	if !idle.IsClosed() || busy.IsClosed() {
+		test.Fatal(idle.IsClosed(), busy.IsClosed())
+	}
+
+	select {
+	case <-closed:
+		test.Fatal("socket answering heartbeats reaped")
+	default:
+	}
+
+	if measurement := harness.AssertMeasurement("websocket", Tags{"type": "r", "reason": "idle"}); measurement.Fields["value"] != 1 {
+		test.Fatal(measurement.Fields)
+	}
+}
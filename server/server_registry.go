@@ -0,0 +1,266 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/server"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type operationDescriptor struct {
+	opcode     uint64
+	resultId   uint64
+	tag        string
+	minimum    int32
+	maximum    int32
+	deprecated bool
+	sunset     time.Time
+	role       Role
+	cacheable  bool
+	sequential bool
+	streaming  bool
+	input      protoreflect.MessageDescriptor
+	output     protoreflect.MessageDescriptor
+}
+
+func describeOperation(operation IOperation) IOperationDescriptor {
+	opcode, resultId := operation.Id()
+	_, streaming := operation.(IStreamingOperation)
+	minimum, maximum := apiVersions(operation)
+	deprecated, sunset := false, time.Time{}
+	if versioned, ok := operation.(IVersionedOperation); ok {
+		deprecated, sunset = versioned.Deprecation()
+	}
+
+	sequential := false
+	if sequentialOperation, ok := operation.(ISequentialOperation); ok {
+		sequential = sequentialOperation.IsSequential()
+	}
+
+	return &operationDescriptor{
+		opcode:     opcode,
+		resultId:   resultId,
+		tag:        operation.Tag(),
+		minimum:    minimum,
+		maximum:    maximum,
+		deprecated: deprecated,
+		sunset:     sunset,
+		role:       operation.Role(),
+		cacheable:  operation.IsCacheable(),
+		sequential: sequential,
+		streaming:  streaming,
+		input:      messageDescriptor(operation.InputContainer()),
+		output:     messageDescriptor(operation.OutputContainer()),
+	}
+}
+
+func messageDescriptor(container interface{}) protoreflect.MessageDescriptor {
+	if message, ok := container.(proto.Message); ok && message != nil {
+		return message.ProtoReflect().Descriptor()
+	}
+
+	return nil
+}
+
+func (descriptor *operationDescriptor) Opcode() uint64 {
+	return descriptor.opcode
+}
+
+func (descriptor *operationDescriptor) ResultId() uint64 {
+	return descriptor.resultId
+}
+
+func (descriptor *operationDescriptor) Tag() string {
+	return descriptor.tag
+}
+
+func (descriptor *operationDescriptor) ApiVersions() (int32, int32) {
+	return descriptor.minimum, descriptor.maximum
+}
+
+func (descriptor *operationDescriptor) Deprecation() (bool, time.Time) {
+	return descriptor.deprecated, descriptor.sunset
+}
+
+func (descriptor *operationDescriptor) Role() Role {
+	return descriptor.role
+}
+
+func (descriptor *operationDescriptor) IsCacheable() bool {
+	return descriptor.cacheable
+}
+
+func (descriptor *operationDescriptor) IsSequential() bool {
+	return descriptor.sequential
+}
+
+func (descriptor *operationDescriptor) IsStreaming() bool {
+	return descriptor.streaming
+}
+
+func (descriptor *operationDescriptor) Input() protoreflect.MessageDescriptor {
+	return descriptor.input
+}
+
+func (descriptor *operationDescriptor) Output() protoreflect.MessageDescriptor {
+	return descriptor.output
+}
+
+func (server *baseServer) OperationDescriptors() []IOperationDescriptor {
+	descriptors := make([]IOperationDescriptor, 0, len(server.operations))
+	for _, versions := range server.versions {
+		for _, operation := range versions {
+			descriptors = append(descriptors, describeOperation(operation))
+		}
+	}
+
+	sort.Slice(descriptors, func(i, j int) bool {
+		if descriptors[i].Opcode() != descriptors[j].Opcode() {
+			return descriptors[i].Opcode() < descriptors[j].Opcode()
+		}
+
+		left, _ := descriptors[i].ApiVersions()
+		right, _ := descriptors[j].ApiVersions()
+		return left < right
+	})
+
+	return descriptors
+}
+
+func (server *baseServer) OperationDescriptor(opcode uint64, apiVersion int32) (IOperationDescriptor, bool) {
+	operation := server.resolveOperation(opcode, apiVersion)
+	if operation == nil {
+		return nil, false
+	}
+
+	return describeOperation(operation), true
+}
+
+type schemaOperation struct {
+	Opcode     uint64 `json:"opcode"`
+	ResultId   uint64 `json:"resultId"`
+	Tag        string `json:"tag"`
+	MinVersion int32  `json:"minApiVersion"`
+	MaxVersion int32  `json:"maxApiVersion"`
+	Deprecated bool   `json:"deprecated"`
+	Sunset     string `json:"sunset,omitempty"`
+	Role       Role   `json:"role"`
+	Cacheable  bool   `json:"cacheable"`
+	Sequential bool   `json:"sequential"`
+	Streaming  bool   `json:"streaming"`
+	Input      string `json:"input"`
+	Output     string `json:"output"`
+}
+
+type schema struct {
+	Version    int32                      `json:"version"`
+	Operations []schemaOperation          `json:"operations"`
+	Messages   map[string]json.RawMessage `json:"messages"`
+	Enums      map[string]json.RawMessage `json:"enums"`
+}
+
+// Schema describes every registered operation along with the protobuf
+// descriptors of all the messages its input and output reference.
+func (server *baseServer) Schema() ([]byte, error) {
+	result := &schema{
+		Version:    server.Version(),
+		Operations: make([]schemaOperation, 0),
+		Messages:   make(map[string]json.RawMessage),
+		Enums:      make(map[string]json.RawMessage),
+	}
+
+	for _, descriptor := range server.OperationDescriptors() {
+		minimum, maximum := descriptor.ApiVersions()
+		deprecated, sunset := descriptor.Deprecation()
+		operation := schemaOperation{
+			Opcode:     descriptor.Opcode(),
+			ResultId:   descriptor.ResultId(),
+			Tag:        descriptor.Tag(),
+			MinVersion: minimum,
+			MaxVersion: maximum,
+			Deprecated: deprecated,
+			Role:       descriptor.Role(),
+			Cacheable:  descriptor.IsCacheable(),
+			Sequential: descriptor.IsSequential(),
+			Streaming:  descriptor.IsStreaming(),
+		}
+
+		if !sunset.IsZero() {
+			operation.Sunset = sunset.UTC().Format(time.RFC3339)
+		}
+
+		if input := descriptor.Input(); input != nil {
+			operation.Input = string(input.FullName())
+			if err := result.collect(input); err != nil {
+				return nil, err
+			}
+		}
+
+		if output := descriptor.Output(); output != nil {
+			operation.Output = string(output.FullName())
+			if err := result.collect(output); err != nil {
+				return nil, err
+			}
+		}
+
+		result.Operations = append(result.Operations, operation)
+	}
+
+	return json.Marshal(result)
+}
+
+func (schema *schema) collect(message protoreflect.MessageDescriptor) error {
+	name := string(message.FullName())
+	if _, exists := schema.Messages[name]; exists {
+		return nil
+	}
+
+	data, err := protojson.Marshal(protodesc.ToDescriptorProto(message))
+	if err != nil {
+		return err
+	}
+
+	schema.Messages[name] = data
+
+	fields := message.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if field.Message() != nil {
+			if err := schema.collect(field.Message()); err != nil {
+				return err
+			}
+		}
+
+		if enum := field.Enum(); enum != nil {
+			if _, exists := schema.Enums[string(enum.FullName())]; !exists {
+				data, err := protojson.Marshal(protodesc.ToEnumDescriptorProto(enum))
+				if err != nil {
+					return err
+				}
+
+				schema.Enums[string(enum.FullName())] = data
+			}
+		}
+	}
+
+	return nil
+}
+
+func (server *baseServer) schemaHandler(writer http.ResponseWriter, _ *http.Request) {
+	data, err := server.Schema()
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	_, _ = writer.Write(data)
+}
@@ -0,0 +1,75 @@
+package server_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/xeronith/diamante/contracts/security"
+	"github.com/xeronith/diamante/server/servertest"
+)
+
+func TestOperationDescriptor(test *testing.T) {
+	harness := servertest.NewHarness(test, &echoOperation{}, &sequentialEchoOperation{})
+	harness.SetRole(100, USER)
+
+	descriptor, exists := harness.OperationDescriptor(100, 0)
+	if !exists {
+		test.Fatal("operation not registered")
+	}
+
+	if descriptor.Opcode() != 100 || descriptor.ResultId() != 101 || descriptor.Tag() != "ECHO" || descriptor.Role() != USER {
+		test.Fatal(descriptor.Opcode(), descriptor.ResultId(), descriptor.Tag(), descriptor.Role())
+	}
+
+	if descriptor.IsCacheable() || descriptor.IsSequential() || descriptor.IsStreaming() {
+		test.Fatal(descriptor.IsCacheable(), descriptor.IsSequential(), descriptor.IsStreaming())
+	}
+
+	if descriptor.Input().FullName() != "protobuf.ServerError" || descriptor.Output().FullName() != "protobuf.ServerError" {
+		test.Fatal(descriptor.Input().FullName(), descriptor.Output().FullName())
+	}
+
+	if descriptor, _ := harness.OperationDescriptor(320, 0); !descriptor.IsSequential() {
+		test.Fatal("sequential operation not described as such")
+	}
+
+	if _, exists := harness.OperationDescriptor(999, 0); exists {
+		test.Fatal("unknown operation described")
+	}
+}
+
+func TestSchema(test *testing.T) {
+	harness := servertest.NewHarness(test, &sequentialEchoOperation{}, &echoOperation{})
+
+	data, err := harness.Schema()
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	schema := &struct {
+		Operations []struct {
+			Opcode     uint64 `json:"opcode"`
+			Tag        string `json:"tag"`
+			Sequential bool   `json:"sequential"`
+			Input      string `json:"input"`
+			Output     string `json:"output"`
+		} `json:"operations"`
+		Messages map[string]json.RawMessage `json:"messages"`
+	}{}
+
+	if err := json.Unmarshal(data, schema); err != nil {
+		test.Fatal(err)
+	}
+
+	if len(schema.Operations) != 2 || schema.Operations[0].Opcode != 100 || schema.Operations[1].Opcode != 320 {
+		test.Fatal(string(data))
+	}
+
+	if operation := schema.Operations[1]; operation.Tag != "SEQUENTIAL_ECHO" || !operation.Sequential || operation.Input != "protobuf.ServerError" {
+		test.Fatal(operation)
+	}
+
+	if _, exists := schema.Messages[schema.Operations[0].Output]; !exists {
+		test.Fatal(string(data))
+	}
+}
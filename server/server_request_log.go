@@ -0,0 +1,126 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/io"
+	. "github.com/xeronith/diamante/contracts/logging"
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/server"
+	. "github.com/xeronith/diamante/contracts/settings"
+	. "github.com/xeronith/diamante/logging"
+)
+
+const REDACTED = "[REDACTED]"
+
+// requestLog writes an access log entry for every pipeline, either to the
+// logger of the server or to a dedicated file. The file holds addresses and
+// identities, so it is readable by its owner only.
+type requestLog struct {
+	mutex      sync.Mutex
+	format     string
+	sampleRate float64
+	redacted   map[string]bool
+	file       *os.File
+}
+
+func newRequestLog(configuration IRequestLogConfiguration, logger ILogger) *requestLog {
+	requestLog := &requestLog{
+		format:     configuration.GetFormat(),
+		sampleRate: configuration.GetSampleRate(),
+		redacted:   make(map[string]bool),
+	}
+
+	for _, field := range configuration.GetRedactedFields() {
+		requestLog.redacted[field] = true
+	}
+
+	if path := configuration.GetPath(); path != "" {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			logger.Error(fmt.Sprintf("REQUEST LOG OPEN ERROR: %s", err))
+		} else {
+			requestLog.file = file
+		}
+	}
+
+	return requestLog
+}
+
+func (requestLog *requestLog) write(logger ILogger, fields []Field) {
+	for index := range fields {
+		if requestLog.redacted[fields[index].Key] {
+			fields[index].Value = REDACTED
+		}
+	}
+
+	line := Format(requestLog.format, fields)
+	if requestLog.file == nil {
+		logger.Info(line)
+		return
+	}
+
+	requestLog.mutex.Lock()
+	defer requestLog.mutex.Unlock()
+
+	if _, err := requestLog.file.WriteString(line + "\n"); err != nil {
+		logger.Error(fmt.Sprintf("REQUEST LOG WRITE ERROR: %s", err))
+	}
+}
+
+func (requestLog *requestLog) close() error {
+	if requestLog.file == nil {
+		return nil
+	}
+
+	return requestLog.file.Close()
+}
+
+// logRequest writes the access log entry of the pipeline when request logging
+// is enabled. Successful requests are sampled at the configured rate while
+// failed ones are always logged.
+func (server *baseServer) logRequest(pipeline IPipeline, request IOperationRequest, result IOperationResult, timestamp time.Time) {
+	if server.requestLog == nil || result == nil {
+		return
+	}
+
+	status := result.Status()
+	if status < BadRequest && server.requestLog.sampleRate < 1 && rand.Float64() >= server.requestLog.sampleRate {
+		return
+	}
+
+	actor := pipeline.Actor()
+
+	identity := int64(0)
+	if actor.Identity() != nil {
+		identity = actor.Identity().Id()
+	}
+
+	transport := ""
+	if writer, ok := actor.Writer().(ITransportWriter); ok {
+		transport = writer.Transport()
+	}
+
+	server.requestLog.write(server.logger, []Field{
+		{Key: "time", Value: timestamp},
+		{Key: "opcode", Value: pipeline.Opcode()},
+		{Key: "tag", Value: server.opcodes[pipeline.Opcode()]},
+		{Key: "request_id", Value: pipeline.RequestId()},
+		{Key: "api_version", Value: pipeline.ApiVersion()},
+		{Key: "client", Value: pipeline.ClientName()},
+		{Key: "transport", Value: transport},
+		{Key: "ip", Value: actor.RemoteAddress()},
+		{Key: "user_agent", Value: actor.UserAgent()},
+		{Key: "identity", Value: identity},
+		{Key: "status", Value: status},
+		{Key: "cached", Value: result.IsCached()},
+		{Key: "request_size", Value: len(request.Payload())},
+		{Key: "response_size", Value: len(result.Payload())},
+		{Key: "execution", Value: result.ExecutionDuration()},
+		{Key: "duration", Value: time.Since(timestamp)},
+	})
+}
@@ -0,0 +1,134 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	. "github.com/xeronith/diamante/contracts/logging"
+	. "github.com/xeronith/diamante/contracts/security"
+	"github.com/xeronith/diamante/logging"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server"
+	"github.com/xeronith/diamante/server/servertest"
+	"github.com/xeronith/diamante/settings"
+)
+
+// capturingLogger keeps what is logged at the info level, where the request
+// log goes when it has no file of its own.
+type capturingLogger struct {
+	ILogger
+	mutex sync.Mutex
+	lines []string
+}
+
+func (logger *capturingLogger) Info(args interface{}) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+
+	logger.lines = append(logger.lines, fmt.Sprint(args))
+}
+
+func (logger *capturingLogger) entries(test *testing.T) []map[string]interface{} {
+	test.Helper()
+
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+
+	entries := make([]map[string]interface{}, 0)
+	for _, line := range logger.lines {
+		entry := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			test.Fatal(line, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	logger.lines = nil
+	return entries
+}
+
+func TestRequestLog(test *testing.T) {
+	create := func(enabled bool, sampleRate float64) (*servertest.Harness, *capturingLogger) {
+		test.Helper()
+
+		configuration := settings.NewTestConfiguration()
+		configuration.(*settings.Configuration).RequestLog = enabled
+		requestLogConfiguration := configuration.GetServerConfiguration().GetRequestLogConfiguration().(*settings.RequestLog)
+		requestLogConfiguration.SampleRate = sampleRate
+		requestLogConfiguration.RedactedFields = []string{"ip", "user_agent"}
+
+		harness := servertest.NewHarnessWithConfiguration(test, configuration, &echoOperation{})
+		harness.SetRole(100, USER)
+
+		logger := &capturingLogger{ILogger: logging.GetDefaultLogger()}
+		harness.SetLogger(logger)
+		return harness, logger
+	}
+
+	test.Run("fields", func(test *testing.T) {
+		harness, logger := create(true, 1)
+
+		identity := harness.NewIdentity(1, USER)
+		if result := harness.Call(harness.PassiveActor(identity), 100, &protobuf.ServerError{Message: "echo"}); result.Status() != server.OK {
+			test.Fatal(result.Status())
+		}
+
+		entries := logger.entries(test)
+		if len(entries) != 1 {
+			test.Fatal(entries)
+		}
+
+		// Numbers are decoded as float64.
+		entry := entries[0]
+		for key, expected := range map[string]interface{}{
+			"opcode":     float64(100),
+			"tag":        "ECHO",
+			"status":     float64(server.OK),
+			"identity":   float64(1),
+			"cached":     false,
+			"ip":         "[REDACTED]",
+			"user_agent": "[REDACTED]",
+		} {
+			if entry[key] != expected {
+				test.Fatal(key, entry[key])
+			}
+		}
+	})
+
+	test.Run("failures", func(test *testing.T) {
+		harness, logger := create(true, 1e-9)
+
+		// Successful requests are sampled away, failed ones are not, and
+		// neither are frames that cannot be decoded.
+		actor := harness.PassiveActor(harness.NewIdentity(1, USER))
+		for count := 0; count < 10; count++ {
+			harness.Call(actor, 100, &protobuf.ServerError{})
+		}
+
+		if result := harness.Call(harness.PassiveActor(nil), 100, &protobuf.ServerError{}); result.Status() != server.Unauthorized {
+			test.Fatal(result.Status())
+		}
+
+		if result := harness.OnData(actor, []byte{0xff, 0xff, 0xff}); result.Status() != server.InternalServerError {
+			test.Fatal(result.Status())
+		}
+
+		entries := logger.entries(test)
+		if len(entries) != 2 || entries[0]["status"] != float64(server.Unauthorized) || entries[1]["status"] != float64(server.InternalServerError) {
+			test.Fatal(entries)
+		}
+	})
+
+	test.Run("disabled", func(test *testing.T) {
+		harness, logger := create(false, 1)
+
+		harness.Call(harness.PassiveActor(nil), 100, &protobuf.ServerError{})
+		harness.OnData(harness.PassiveActor(nil), []byte{0xff, 0xff, 0xff})
+		if entries := logger.entries(test); len(entries) != 0 {
+			test.Fatal(entries)
+		}
+	})
+}
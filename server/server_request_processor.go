@@ -1,7 +1,10 @@
 package server
 
 import (
+	gocontext "context"
+	"errors"
 	"sync/atomic"
+	"time"
 
 	. "github.com/xeronith/diamante/contracts/operation"
 	. "github.com/xeronith/diamante/contracts/server"
@@ -10,20 +13,47 @@ import (
 )
 
 func (server *baseServer) OnOperationRequest(pipeline IPipeline, request IOperationRequest) IOperationResult {
+	return server.intercept(pipeline, request, 0)
+}
+
+func (server *baseServer) processOperationRequest(pipeline IPipeline, request IOperationRequest) IOperationResult {
+	// Batch envelopes have no operation of their own and their requests are
+	// authorized one by one, but the envelope counts against the rate limits.
+	if pipeline.Opcode() == BATCH_REQUEST {
+		if allowed, retryAfter := server.throttle(pipeline); !allowed {
+			return pipeline.TooManyRequests(retryAfter)
+		}
+
+		return server.processBatch(pipeline, request)
+	}
+
 	operation := pipeline.Operation()
 	if operation == nil {
+		if len(server.versions[pipeline.Opcode()]) > 0 {
+			return pipeline.BadRequest(UNSUPPORTED_API_VERSION)
+		}
+
 		return pipeline.NotImplemented()
 	}
 
+	if deprecated, sunset := pipeline.Deprecation(); deprecated {
+		if !sunset.IsZero() && time.Now().After(sunset) {
+			return pipeline.Gone(API_VERSION_SUNSET)
+		}
+
+		server.warnDeprecation(pipeline)
+	}
+
 	if err := server.authorize(pipeline); err != nil {
 		return pipeline.Unauthorized()
 	}
 
-	if item, exists := server.cache.Get(pipeline.Signature()); exists {
-		result := item.(IOperationResult)
-		if pipeline.IsAcceptable(result) {
-			return result.UpdateStat(true, server.cacheMiss, atomic.AddInt64(&server.cacheHit, 1))
-		}
+	if allowed, retryAfter := server.throttle(pipeline); !allowed {
+		return pipeline.TooManyRequests(retryAfter)
+	}
+
+	if result, exists := server.cacheLookup(pipeline); exists {
+		return result
 	}
 
 	container := operation.InputContainer()
@@ -37,10 +67,16 @@ func (server *baseServer) OnOperationRequest(pipeline IPipeline, request IOperat
 	}
 
 	context := server.acquireContext(pipeline)
+	defer context.release()
+
+	if _, ok := operation.(IStreamingOperation); ok {
+		return server.processStream(context, container, pipeline)
+	}
+
 	output, duration, err := server.executeService(context, container, pipeline)
 
 	if err != nil {
-		return pipeline.InternalServerError(err)
+		return server.serviceError(pipeline, err)
 	}
 
 	if output == nil || !IsPointer(output) {
@@ -51,10 +87,16 @@ func (server *baseServer) OnOperationRequest(pipeline IPipeline, request IOperat
 		return pipeline.InternalServerError(err)
 	} else {
 		result := CreateOperationResult(pipeline.RequestId(), OK, context.ResultType(), payload, pipeline, duration)
-		if operation.IsCacheable() {
-			server.cache.Put(result.Signature(), result)
-		}
+		server.cacheStore(pipeline, result)
+
+		return result.UpdateStat(false, atomic.AddInt64(&server.cacheMiss, 1), atomic.LoadInt64(&server.cacheHit))
+	}
+}
 
-		return result.UpdateStat(false, atomic.AddInt64(&server.cacheMiss, 1), server.cacheHit)
+func (server *baseServer) serviceError(pipeline IPipeline, err error) IOperationResult {
+	if errors.Is(err, GATEWAY_TIMEOUT) || errors.Is(err, gocontext.DeadlineExceeded) {
+		return pipeline.GatewayTimeout()
 	}
+
+	return pipeline.InternalServerError(err)
 }
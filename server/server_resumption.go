@@ -0,0 +1,174 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/actor"
+	. "github.com/xeronith/diamante/contracts/analytics"
+	. "github.com/xeronith/diamante/contracts/io"
+	. "github.com/xeronith/diamante/contracts/server"
+	. "github.com/xeronith/diamante/io"
+	. "github.com/xeronith/diamante/operation"
+	. "github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/utility"
+)
+
+type resumableSession struct {
+	actor  IActor
+	writer IResumableWriter
+	timer  *time.Timer
+}
+
+// resumption keeps the resumable actors of this node by resume token. Every
+// connection gets a new token, so a token can be used only once.
+type resumption struct {
+	sync.Mutex
+	sessions map[string]*resumableSession
+	tokens   map[IActor]string
+}
+
+func newResumption() *resumption {
+	return &resumption{
+		sessions: make(map[string]*resumableSession),
+		tokens:   make(map[IActor]string),
+	}
+}
+
+func (resumption *resumption) register(actor IActor, writer IResumableWriter) string {
+	token := utility.GenerateUUID()
+
+	resumption.Lock()
+	defer resumption.Unlock()
+
+	resumption.unlink(actor)
+	resumption.sessions[token] = &resumableSession{actor: actor, writer: writer}
+	resumption.tokens[actor] = token
+
+	return token
+}
+
+func (resumption *resumption) take(token string) (*resumableSession, bool) {
+	resumption.Lock()
+	defer resumption.Unlock()
+
+	session, exists := resumption.sessions[token]
+	if !exists {
+		return nil, false
+	}
+
+	resumption.unlink(session.actor)
+	return session, true
+}
+
+func (resumption *resumption) suspend(actor IActor, gracePeriod time.Duration, expire func()) bool {
+	resumption.Lock()
+	defer resumption.Unlock()
+
+	session, exists := resumption.sessions[resumption.tokens[actor]]
+	if !exists {
+		return false
+	}
+
+	if session.timer != nil {
+		session.timer.Stop()
+	}
+
+	session.timer = time.AfterFunc(gracePeriod, expire)
+	return true
+}
+
+func (resumption *resumption) remove(actor IActor) {
+	resumption.Lock()
+	defer resumption.Unlock()
+
+	resumption.unlink(actor)
+}
+
+func (resumption *resumption) unlink(actor IActor) {
+	token, exists := resumption.tokens[actor]
+	if !exists {
+		return
+	}
+
+	if session := resumption.sessions[token]; session.timer != nil {
+		session.timer.Stop()
+	}
+
+	delete(resumption.sessions, token)
+	delete(resumption.tokens, actor)
+}
+
+// connectActor creates the actor of a new socket, or reattaches the socket to
+// the actor of the resume token and replays the messages it has missed. Either
+// way the client is first sent a Resumption with the token of its next resume.
+func (server *defaultServer) connectActor(writer IWriter, resumeToken string, createActor func(IWriter) IActor) IActor {
+	webSocketConfiguration := server.configuration.GetServerConfiguration().GetWebSocketConfiguration()
+	if webSocketConfiguration.GetResumptionGracePeriod() <= 0 {
+		return createActor(writer)
+	}
+
+	if resumeToken != "" {
+		if session, exists := server.resumption.take(resumeToken); exists && session.writer.IsOpen() {
+			server.sendResumption(writer, server.resumption.register(session.actor, session.writer), true)
+			if session.writer.Attach(writer) {
+				server.measurement("websocket", Tags{"type": "c", "reason": "resumed"}, Fields{"value": 1})
+				return session.actor
+			}
+
+			server.resumption.remove(session.actor)
+			writer.Close()
+			return nil
+		}
+	}
+
+	var actor IActor
+	resumableWriter := CreateResumableWriter(server, writer, webSocketConfiguration.GetResumptionQueueSize(), func() {
+		server.OnSocketDisconnected(actor)
+	})
+
+	actor = createActor(resumableWriter)
+	server.sendResumption(writer, server.resumption.register(actor, resumableWriter), false)
+	return actor
+}
+
+// onSocketClosed suspends the actor of a dropped socket for the configured
+// grace period when resumption is enabled, and disconnects it otherwise.
+// Sockets that have been taken over by a resumed connection are ignored.
+func (server *baseServer) onSocketClosed(actor IActor, writer IWriter, resumable bool) {
+	if actor == nil {
+		return
+	}
+
+	resumableWriter, ok := actor.Writer().(IResumableWriter)
+	if !ok {
+		server.OnSocketDisconnected(actor)
+		return
+	}
+
+	if !resumableWriter.Detach(writer) {
+		return
+	}
+
+	gracePeriod := server.configuration.GetServerConfiguration().GetWebSocketConfiguration().GetResumptionGracePeriod()
+	if resumable && server.resumption.suspend(actor, gracePeriod, resumableWriter.Close) {
+		server.presence.untrack(actor)
+		return
+	}
+
+	resumableWriter.Close()
+}
+
+func (server *baseServer) sendResumption(writer IWriter, token string, resumed bool) {
+	payload, err := server.serializers["application/octet-stream"].Serialize(&Resumption{
+		Token:   token,
+		Resumed: resumed,
+	})
+
+	if err != nil {
+		server.logger.Error(err)
+		return
+	}
+
+	writer.Write(CreateOperationResult(BROADCAST, OK, RESUMPTION_RESULT, payload, NO_PIPELINE_INFO, 0))
+}
@@ -0,0 +1,219 @@
+package server_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/server"
+	"github.com/xeronith/diamante/messaging"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server"
+	"github.com/xeronith/diamante/server/servertest"
+	"github.com/xeronith/diamante/settings"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestResumption(test *testing.T) {
+	harness := servertest.NewHarness(test, &echoOperation{})
+	harness.SetRole(100, USER)
+
+	webSocketConfiguration := harness.Configuration().GetServerConfiguration().GetWebSocketConfiguration().(*settings.WebSocket)
+	webSocketConfiguration.ResumptionGracePeriod = "300ms"
+	webSocketConfiguration.ResumptionQueueSize = 2
+	webSocketConfiguration.IdleTimeout = "100ms"
+
+	disconnected := make(chan string, 64)
+	harness.OnActorDisconnected(func(token string) { disconnected <- token })
+	harness.Serve()
+
+	read := func(connection *websocket.Conn) *protobuf.OperationResult {
+		test.Helper()
+
+		_ = connection.SetReadDeadline(time.Now().Add(servertest.AWAIT_TIMEOUT))
+		_, data, err := connection.ReadMessage()
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		result := &protobuf.OperationResult{}
+		if err := proto.Unmarshal(data, result); err != nil {
+			test.Fatal(err)
+		}
+
+		return result
+	}
+
+	message := func(connection *websocket.Conn) string {
+		test.Helper()
+
+		result, output := read(connection), &protobuf.ServerError{}
+		if result.Type != 101 || proto.Unmarshal(result.Payload, output) != nil {
+			test.Fatal(result.Type)
+		}
+
+		return output.Message
+	}
+
+	// Every connection opens with the token of its next resume.
+	dial := func(resumeToken string) (*websocket.Conn, string, bool) {
+		test.Helper()
+
+		connection, _, err := websocket.DefaultDialer.Dial(harness.ActiveEndpoint()+"/?resume="+resumeToken, nil)
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		test.Cleanup(func() { _ = connection.Close() })
+
+		result, resumption := read(connection), &protobuf.Resumption{}
+		if result.Type != RESUMPTION_RESULT || proto.Unmarshal(result.Payload, resumption) != nil || resumption.Token == "" {
+			test.Fatal(result.Type)
+		}
+
+		return connection, resumption.Token, resumption.Resumed
+	}
+
+	// connect opens a socket on behalf of the identity, so its presence
+	// tells when the server has noticed the socket drop.
+	connect := func(identity Identity) (*websocket.Conn, string) {
+		test.Helper()
+
+		connection, resumeToken, _ := dial("")
+		payload, _ := proto.Marshal(&protobuf.ServerError{Message: "hello"})
+		data, _ := proto.Marshal(&protobuf.OperationRequest{Id: 1, Operation: 100, Token: identity.Token(), Payload: payload})
+		if err := connection.WriteMessage(websocket.BinaryMessage, data); err != nil {
+			test.Fatal(err)
+		}
+
+		if result := read(connection); result.Status != server.OK {
+			test.Fatal(result.Status)
+		}
+
+		return connection, resumeToken
+	}
+
+	drop := func(connection *websocket.Conn, identity Identity) {
+		test.Helper()
+
+		_ = connection.UnderlyingConn().Close()
+		for deadline := time.Now().Add(servertest.AWAIT_TIMEOUT); harness.Presence().IsOnline(identity.Id()); {
+			if time.Now().After(deadline) {
+				test.Fatal("drop not noticed")
+			}
+
+			time.Sleep(time.Millisecond * 5)
+		}
+	}
+
+	// Pushes are used rather than broadcasts, which are delivered in no
+	// particular order.
+	push := func(identity Identity, messages ...string) {
+		test.Helper()
+
+		for _, message := range messages {
+			if err := harness.PushToken(identity.Token(), messaging.NewPushMessage(101, &protobuf.ServerError{Message: message})); err != nil {
+				test.Fatal(err)
+			}
+		}
+	}
+
+	awaitDisconnected := func(identity Identity) {
+		test.Helper()
+
+		for {
+			select {
+			case token := <-disconnected:
+				if token == identity.Token() {
+					return
+				}
+			case <-time.After(servertest.AWAIT_TIMEOUT):
+				test.Fatal("not disconnected")
+			}
+		}
+	}
+
+	test.Run("resume", func(test *testing.T) {
+		identity := harness.NewIdentity(1, USER)
+		connection, resumeToken := connect(identity)
+		drop(connection, identity)
+
+		// Messages sent while suspended are replayed in order on resume.
+		push(identity, "first", "second")
+
+		connection, nextToken, resumed := dial(resumeToken)
+		if !resumed || nextToken == resumeToken {
+			test.Fatal(resumed, nextToken)
+		}
+
+		for _, expected := range []string{"first", "second"} {
+			if output := message(connection); output != expected {
+				test.Fatal(output, expected)
+			}
+		}
+
+		if !harness.Presence().IsOnline(1) {
+			test.Fatal("resumed actor offline")
+		}
+
+		// Every resume token can be used only once.
+		if _, _, resumed := dial(resumeToken); resumed {
+			test.Fatal("resume token reused")
+		}
+
+		push(identity, "live")
+		if output := message(connection); output != "live" {
+			test.Fatal(output)
+		}
+
+		_ = connection.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		awaitDisconnected(identity)
+	})
+
+	test.Run("overflow", func(test *testing.T) {
+		identity := harness.NewIdentity(2, USER)
+		connection, resumeToken := connect(identity)
+		drop(connection, identity)
+
+		// The third message overflows the queue, which ends the session.
+		push(identity, "first", "second", "third")
+		awaitDisconnected(identity)
+
+		if _, _, resumed := dial(resumeToken); resumed {
+			test.Fatal("overflowed session resumed")
+		}
+	})
+
+	test.Run("expiry", func(test *testing.T) {
+		identity := harness.NewIdentity(3, USER)
+		connection, resumeToken := connect(identity)
+
+		dropped := time.Now()
+		drop(connection, identity)
+		awaitDisconnected(identity)
+
+		if elapsed := time.Since(dropped); elapsed < time.Millisecond*250 {
+			test.Fatal("expired early", elapsed)
+		}
+
+		if _, _, resumed := dial(resumeToken); resumed {
+			test.Fatal("expired session resumed")
+		}
+	})
+
+	test.Run("reaper", func(test *testing.T) {
+		identity := harness.NewIdentity(4, USER)
+		connection, resumeToken := connect(identity)
+		drop(connection, identity)
+
+		// An actor reaped within its grace period is gone for good.
+		time.Sleep(time.Millisecond * 150)
+		harness.Clock().Advance(time.Second)
+		awaitDisconnected(identity)
+
+		if _, _, resumed := dial(resumeToken); resumed {
+			test.Fatal("reaped session resumed")
+		}
+	})
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	. "github.com/xeronith/diamante/contracts/scheduling"
@@ -12,6 +13,8 @@ import (
 type scheduler struct {
 	futuresChannel chan *future
 	futures        map[string]*future
+	quit           chan struct{}
+	stopOnce       sync.Once
 }
 
 type future struct {
@@ -26,15 +29,19 @@ type future struct {
 func newScheduler() IScheduler {
 	return &scheduler{
 		futuresChannel: make(chan *future, 1000),
+		quit:           make(chan struct{}),
 	}
 }
 
 func (scheduler *scheduler) Start() {
 	scheduler.futures = make(map[string]*future)
 	ticker := time.NewTicker(time.Millisecond * 100)
+	defer ticker.Stop()
 
 	for {
 		select {
+		case <-scheduler.quit:
+			return
 		case future := <-scheduler.futuresChannel:
 			scheduler.futures[future.id] = future
 		case <-ticker.C:
@@ -62,6 +69,12 @@ func (scheduler *scheduler) Start() {
 	}
 }
 
+func (scheduler *scheduler) Stop() {
+	scheduler.stopOnce.Do(func() {
+		close(scheduler.quit)
+	})
+}
+
 func catch() {
 	if reason := recover(); reason != nil {
 		logging.GetDefaultLogger().Panic(fmt.Sprintf("SCHEDULER: %s", reason))
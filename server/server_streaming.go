@@ -0,0 +1,65 @@
+package server
+
+import (
+	"sync"
+
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/server"
+	. "github.com/xeronith/diamante/contracts/system"
+	. "github.com/xeronith/diamante/operation"
+)
+
+type streamContext struct {
+	*context
+	mutex     sync.Mutex
+	completed bool
+}
+
+func (context *context) stream() *streamContext {
+	return &streamContext{
+		context: context,
+	}
+}
+
+func (context *streamContext) Emit(output Pointer) error {
+	context.mutex.Lock()
+	defer context.mutex.Unlock()
+
+	if context.completed {
+		return STREAM_COMPLETED
+	}
+
+	if err := context.ctx.Err(); err != nil {
+		return err
+	}
+
+	payload, err := context.pipeline.Serializer().Serialize(output)
+	if err != nil {
+		return err
+	}
+
+	context.actor.Dispatch(CreatePartialOperationResult(context.requestId, context.resultType, payload, context.pipeline))
+	return nil
+}
+
+func (context *streamContext) Complete() {
+	context.mutex.Lock()
+	defer context.mutex.Unlock()
+
+	context.completed = true
+}
+
+func (server *baseServer) processStream(context *context, container Pointer, pipeline IPipeline) IOperationResult {
+	stream := context.stream()
+	_, duration, err := server.executeService(stream, container, pipeline)
+
+	// Nothing may follow the end marker, even if the operation is still
+	// running after a timeout.
+	stream.Complete()
+
+	if err != nil {
+		return server.serviceError(pipeline, err)
+	}
+
+	return CreateOperationResult(pipeline.RequestId(), OK, context.ResultType(), nil, pipeline, duration)
+}
@@ -0,0 +1,189 @@
+package server_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/service"
+	. "github.com/xeronith/diamante/contracts/system"
+	"github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server"
+	"github.com/xeronith/diamante/server/servertest"
+	"github.com/xeronith/diamante/settings"
+	"google.golang.org/protobuf/proto"
+)
+
+type streamOperation struct {
+	operation.StreamingOperation
+	emitted chan error
+}
+
+func (operation *streamOperation) Tag() string              { return "STREAM" }
+func (operation *streamOperation) Id() (ID, ID)             { return 350, 351 }
+func (operation *streamOperation) InputContainer() Pointer  { return &protobuf.ServerError{} }
+func (operation *streamOperation) OutputContainer() Pointer { return &protobuf.ServerError{} }
+func (operation *streamOperation) ExecuteStream(context IStreamContext, payload Pointer) error {
+	message := payload.(*protobuf.ServerError).Message
+	if message == "wait" {
+		// Runs until the stream is cancelled, and reports whether it can
+		// still emit afterwards.
+		<-context.Context().Done()
+		operation.emitted <- context.Emit(&protobuf.ServerError{})
+		return context.Context().Err()
+	}
+
+	for _, suffix := range []string{"1", "2"} {
+		if err := context.Emit(&protobuf.ServerError{Message: message + suffix}); err != nil {
+			return err
+		}
+	}
+
+	// Nothing may be emitted after the end of the stream.
+	context.Complete()
+	if err := context.Emit(&protobuf.ServerError{Message: "late"}); err != server.STREAM_COMPLETED {
+		return errors.New("emitted after completion")
+	}
+
+	return nil
+}
+
+func newStreamOperation() *streamOperation {
+	return &streamOperation{emitted: make(chan error, 1)}
+}
+
+func streamRequest(test *testing.T, message string) []byte {
+	test.Helper()
+
+	payload, _ := proto.Marshal(&protobuf.ServerError{Message: message})
+	data, err := proto.Marshal(&protobuf.OperationRequest{Id: 1, Operation: 350, Payload: payload})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	return data
+}
+
+func assertStream(test *testing.T, results []*protobuf.OperationResult) {
+	test.Helper()
+
+	if len(results) != 3 {
+		test.Fatal(results)
+	}
+
+	for index, expected := range []string{"stream1", "stream2"} {
+		output := &protobuf.ServerError{}
+		if !results[index].Partial || results[index].Id != 1 || proto.Unmarshal(results[index].Payload, output) != nil || output.Message != expected {
+			test.Fatal(index, results[index].Partial, output.Message)
+		}
+	}
+
+	if end := results[2]; end.Partial || end.Id != 1 || end.Status != server.OK {
+		test.Fatal(end.Partial, end.Status)
+	}
+}
+
+func TestStream(test *testing.T) {
+	harness := servertest.NewHarness(test, newStreamOperation())
+	harness.SetRole(350, ANONYMOUS)
+	harness.Serve()
+
+	test.Run("websocket", func(test *testing.T) {
+		connection, _, err := websocket.DefaultDialer.Dial(harness.ActiveEndpoint(), nil)
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		defer connection.Close()
+
+		if err := connection.WriteMessage(websocket.BinaryMessage, streamRequest(test, "stream")); err != nil {
+			test.Fatal(err)
+		}
+
+		results := make([]*protobuf.OperationResult, 0)
+		for len(results) == 0 || results[len(results)-1].Partial {
+			_, data, err := connection.ReadMessage()
+			if err != nil {
+				test.Fatal(err)
+			}
+
+			result := &protobuf.OperationResult{}
+			if err := proto.Unmarshal(data, result); err != nil {
+				test.Fatal(err)
+			}
+
+			if result.Id == 1 {
+				results = append(results, result)
+			}
+		}
+
+		assertStream(test, results)
+	})
+
+	test.Run("sse", func(test *testing.T) {
+		response, err := http.Post(harness.PassiveEndpoint(), "application/octet-stream", bytes.NewReader(streamRequest(test, "stream")))
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		defer response.Body.Close()
+
+		if !strings.HasPrefix(response.Header.Get("Content-Type"), "text/event-stream") {
+			test.Fatal(response.Header.Get("Content-Type"))
+		}
+
+		events, results := make([]string, 0), make([]*protobuf.OperationResult, 0)
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if event := strings.TrimPrefix(line, "event: "); event != line {
+				events = append(events, event)
+			} else if encoded := strings.TrimPrefix(line, "data: "); encoded != line {
+				data, err := base64.StdEncoding.DecodeString(encoded)
+				if err != nil {
+					test.Fatal(err)
+				}
+
+				result := &protobuf.OperationResult{}
+				if err := proto.Unmarshal(data, result); err != nil {
+					test.Fatal(err)
+				}
+
+				results = append(results, result)
+			}
+		}
+
+		if strings.Join(events, ",") != "partial,partial,end" {
+			test.Fatal(events)
+		}
+
+		assertStream(test, results)
+	})
+}
+
+func TestStream_Timeout(test *testing.T) {
+	stream := newStreamOperation()
+	harness := servertest.NewHarness(test, stream)
+	harness.SetRole(350, ANONYMOUS)
+	harness.Configuration().GetServerConfiguration().GetStreamingConfiguration().(*settings.Streaming).Timeout = "50ms"
+
+	actor := harness.Connect(nil)
+	if result := harness.Call(actor, 350, &protobuf.ServerError{Message: "wait"}); result.Status() != server.GatewayTimeout {
+		test.Fatal(result.Status())
+	}
+
+	if err := <-stream.emitted; err == nil {
+		test.Fatal("emitted after the timeout")
+	}
+
+	if len(actor.Results()) != 0 {
+		test.Fatal(actor.Results())
+	}
+}
@@ -0,0 +1,21 @@
+package server_test
+
+import (
+	. "github.com/xeronith/diamante/contracts/service"
+	. "github.com/xeronith/diamante/contracts/system"
+	"github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+)
+
+type echoOperation struct {
+	operation.Operation
+}
+
+func (operation *echoOperation) Tag() string              { return "ECHO" }
+func (operation *echoOperation) Id() (ID, ID)             { return 100, 101 }
+func (operation *echoOperation) InputContainer() Pointer  { return &protobuf.ServerError{} }
+func (operation *echoOperation) OutputContainer() Pointer { return &protobuf.ServerError{} }
+func (operation *echoOperation) IsCacheable() bool        { return false }
+func (operation *echoOperation) Execute(_ IContext, payload Pointer) (Pointer, error) {
+	return &protobuf.ServerError{Message: payload.(*protobuf.ServerError).Message}, nil
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/analytics"
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/server"
+	. "github.com/xeronith/diamante/contracts/throttling"
+)
+
+func (server *baseServer) RateLimiter() IRateLimiter {
+	return server.rateLimiter
+}
+
+func (server *baseServer) SetRateLimiter(limiter IRateLimiter) {
+	server.rateLimiter = limiter
+}
+
+func (server *baseServer) throttle(pipeline IPipeline) (bool, time.Duration) {
+	if server.rateLimiter == nil || pipeline.IsSystemCall() {
+		return true, 0
+	}
+
+	actor := pipeline.Actor()
+	role := ANONYMOUS
+	subject := "a:" + actor.RemoteAddress()
+
+	if identity := actor.Identity(); identity != nil {
+		role = identity.Role()
+		if identity.Id() > 0 {
+			subject = fmt.Sprintf("i:%d", identity.Id())
+		} else if identity.Token() != "" {
+			subject = "t:" + identity.Token()
+		}
+	}
+
+	allowed, retryAfter := server.rateLimiter.Allow(pipeline.Opcode(), role, subject)
+	if !allowed {
+		server.measurement(
+			"operations",
+			Tags{"type": "l"},
+			Fields{
+				"operation": int64(pipeline.Opcode()),
+				"requestId": int64(pipeline.RequestId()),
+			},
+		)
+	}
+
+	return allowed, retryAfter
+}
+
+// rateLimitSystemCall handles:
+//
+//	ratelimit <opcode|*> <role|*> <rate> <burst>
+//	ratelimit <opcode|*> <role|*> off
+func (server *baseServer) rateLimitSystemCall(args []string) error {
+	if server.rateLimiter == nil {
+		return errors.New("no rate limiter")
+	}
+
+	if len(args) < 4 {
+		return INVALID_PARAMETERS
+	}
+
+	opcode := ANY_OPCODE
+	if args[1] != "*" {
+		value, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil || value == SYSTEM_CALL_REQUEST {
+			return INVALID_PARAMETERS
+		}
+
+		if _, exists := server.operations[value]; !exists {
+			return INVALID_PARAMETERS
+		}
+
+		opcode = value
+	}
+
+	role := ANY_ROLE
+	if args[2] != "*" {
+		value, err := strconv.ParseUint(args[2], 10, 64)
+		if err != nil {
+			return INVALID_PARAMETERS
+		}
+
+		role = value
+	}
+
+	if args[3] == "off" {
+		server.rateLimiter.RemoveLimit(opcode, role)
+		return nil
+	}
+
+	if len(args) < 5 {
+		return INVALID_PARAMETERS
+	}
+
+	rate, err := strconv.ParseFloat(args[3], 64)
+	if err != nil || rate <= 0 {
+		return INVALID_PARAMETERS
+	}
+
+	burst, err := strconv.Atoi(args[4])
+	if err != nil || burst < 1 {
+		return INVALID_PARAMETERS
+	}
+
+	server.rateLimiter.SetLimit(opcode, role, rate, burst)
+	return nil
+}
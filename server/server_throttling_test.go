@@ -0,0 +1,182 @@
+package server_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/server"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server"
+	"github.com/xeronith/diamante/server/servertest"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestThrottling(test *testing.T) {
+	harness := servertest.NewHarness(test, &echoOperation{}, &systemCallOperation{})
+	harness.SetRole(100, ANONYMOUS)
+	harness.SetRole(SYSTEM_CALL_REQUEST, ANONYMOUS)
+	harness.Serve()
+
+	call := func(actor *servertest.Actor) IOperationResult {
+		test.Helper()
+
+		return harness.Call(actor, 100, &protobuf.ServerError{Message: "echo"})
+	}
+
+	systemCall := func(command string) error {
+		test.Helper()
+
+		return harness.Invoke(harness.PassiveActor(nil), SYSTEM_CALL_REQUEST, &protobuf.ServerError{Message: command}, &protobuf.ServerError{})
+	}
+
+	limit := func(command string) {
+		test.Helper()
+
+		if err := systemCall(command); err != nil {
+			test.Fatal(command, err)
+		}
+	}
+
+	throttled := func(result IOperationResult) {
+		test.Helper()
+
+		serverError := &protobuf.ServerError{}
+		if result.Status() != server.TooManyRequests || harness.Decode(result, serverError) != nil || serverError.RetryAfter <= 0 {
+			test.Fatal(result.Status(), serverError.RetryAfter)
+		}
+	}
+
+	for _, command := range []string{
+		"ratelimit",
+		"ratelimit 100 *",
+		"ratelimit 100 * 1",
+		"ratelimit 999 * 1 1",
+		"ratelimit x * 1 1",
+		"ratelimit 100 x 1 1",
+		"ratelimit 100 * 0 1",
+		"ratelimit 100 * 1 0",
+		fmt.Sprintf("ratelimit %d * 1 1", SYSTEM_CALL_REQUEST),
+	} {
+		if err := systemCall(command); err == nil {
+			test.Fatal(command)
+		}
+	}
+
+	// A rate of one request in a thousand seconds keeps exhausted buckets
+	// from refilling for the length of the test.
+	test.Run("status", func(test *testing.T) {
+		limit("ratelimit 100 * 0.001 2")
+		defer limit("ratelimit 100 * off")
+
+		actor := harness.PassiveActor(harness.NewIdentity(1, USER))
+		for count := 0; count < 2; count++ {
+			if result := call(actor); result.Status() != server.OK {
+				test.Fatal(count, result.Status())
+			}
+		}
+
+		throttled(call(actor))
+
+		// System calls are never throttled.
+		limit("ratelimit * * 0.001 1")
+		defer limit("ratelimit * * off")
+
+		for count := 0; count < 3; count++ {
+			limit("ratelimit * * 0.001 1")
+		}
+	})
+
+	test.Run("header", func(test *testing.T) {
+		limit("ratelimit 100 * 0.001 1")
+		defer limit("ratelimit 100 * off")
+
+		post := func() *http.Response {
+			test.Helper()
+
+			payload, _ := proto.Marshal(&protobuf.ServerError{Message: "echo"})
+			data, _ := proto.Marshal(&protobuf.OperationRequest{Id: 1, Operation: 100, Payload: payload})
+			response, err := http.Post(harness.PassiveEndpoint(), "application/octet-stream", bytes.NewReader(data))
+			if err != nil {
+				test.Fatal(err)
+			}
+
+			readAll(test, response)
+			return response
+		}
+
+		if response := post(); response.StatusCode != http.StatusOK || response.Header.Get("Retry-After") != "" {
+			test.Fatal(response.StatusCode, response.Header.Get("Retry-After"))
+		}
+
+		response := post()
+		if response.StatusCode != http.StatusTooManyRequests {
+			test.Fatal(response.StatusCode)
+		}
+
+		// The header is in whole seconds, rounded up.
+		if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err != nil || seconds < 1 {
+			test.Fatal(response.Header.Get("Retry-After"), err)
+		}
+	})
+
+	test.Run("role", func(test *testing.T) {
+		limit(fmt.Sprintf("ratelimit 100 %d 0.001 1", USER))
+		defer limit(fmt.Sprintf("ratelimit 100 %d off", USER))
+
+		user, anonymous := harness.PassiveActor(harness.NewIdentity(2, USER)), harness.PassiveActor(nil)
+		if result := call(user); result.Status() != server.OK {
+			test.Fatal(result.Status())
+		}
+
+		throttled(call(user))
+
+		// Roles without a limit of their own are not throttled.
+		for count := 0; count < 3; count++ {
+			if result := call(anonymous); result.Status() != server.OK {
+				test.Fatal(count, result.Status())
+			}
+		}
+
+		// The limit of a role takes precedence over that of any role.
+		limit("ratelimit 100 * 0.001 100")
+		defer limit("ratelimit 100 * off")
+
+		throttled(call(user))
+	})
+
+	test.Run("caller", func(test *testing.T) {
+		limit("ratelimit 100 * 0.001 1")
+		defer limit("ratelimit 100 * off")
+
+		// Identities have buckets of their own, while anonymous callers
+		// share the bucket of their address.
+		first, second := harness.PassiveActor(harness.NewIdentity(3, USER)), harness.PassiveActor(harness.NewIdentity(4, USER))
+		for _, actor := range []*servertest.Actor{first, second} {
+			if result := call(actor); result.Status() != server.OK {
+				test.Fatal(result.Status())
+			}
+		}
+
+		throttled(call(first))
+		throttled(call(second))
+
+		// The http requests above came from the same address.
+		harness.RateLimiter().Store().Reset(fmt.Sprintf("%x:a:%s", 100, servertest.REMOTE_ADDRESS))
+		if result := call(harness.PassiveActor(nil)); result.Status() != server.OK {
+			test.Fatal(result.Status())
+		}
+
+		throttled(call(harness.PassiveActor(nil)))
+
+		// Removing the limit lets every caller through again.
+		limit("ratelimit 100 * off")
+		if result := call(first); result.Status() != server.OK {
+			test.Fatal(result.Status())
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"errors"
+	"sync"
+
+	. "github.com/xeronith/diamante/contracts/actor"
+	. "github.com/xeronith/diamante/contracts/system"
+	"github.com/xeronith/diamante/utility/reflection"
+)
+
+type topics struct {
+	sync.RWMutex
+	subscribers   map[string]map[IActor]struct{}
+	subscriptions map[IActor]map[string]struct{}
+}
+
+func newTopics() *topics {
+	return &topics{
+		subscribers:   make(map[string]map[IActor]struct{}),
+		subscriptions: make(map[IActor]map[string]struct{}),
+	}
+}
+
+func (topics *topics) add(actor IActor, topic string) {
+	topics.Lock()
+	defer topics.Unlock()
+
+	if topics.subscribers[topic] == nil {
+		topics.subscribers[topic] = make(map[IActor]struct{})
+	}
+
+	if topics.subscriptions[actor] == nil {
+		topics.subscriptions[actor] = make(map[string]struct{})
+	}
+
+	topics.subscribers[topic][actor] = struct{}{}
+	topics.subscriptions[actor][topic] = struct{}{}
+}
+
+func (topics *topics) remove(actor IActor, topic string) {
+	topics.Lock()
+	defer topics.Unlock()
+
+	topics.unlink(actor, topic)
+}
+
+func (topics *topics) removeAll(actor IActor) {
+	topics.Lock()
+	defer topics.Unlock()
+
+	for topic := range topics.subscriptions[actor] {
+		topics.unlink(actor, topic)
+	}
+}
+
+func (topics *topics) unlink(actor IActor, topic string) {
+	if subscribers, exists := topics.subscribers[topic]; exists {
+		delete(subscribers, actor)
+		if len(subscribers) == 0 {
+			delete(topics.subscribers, topic)
+		}
+	}
+
+	if subscriptions, exists := topics.subscriptions[actor]; exists {
+		delete(subscriptions, topic)
+		if len(subscriptions) == 0 {
+			delete(topics.subscriptions, actor)
+		}
+	}
+}
+
+func (topics *topics) get(topic string) []IActor {
+	topics.RLock()
+	defer topics.RUnlock()
+
+	subscribers := make([]IActor, 0, len(topics.subscribers[topic]))
+	for actor := range topics.subscribers[topic] {
+		subscribers = append(subscribers, actor)
+	}
+
+	return subscribers
+}
+
+// Subscribe adds the actor to the subscribers of the topic. Only actors
+// with a persistent connection can subscribe, since the result of a
+// request-response actor is written only once.
+func (server *baseServer) Subscribe(actor IActor, topic string) error {
+	if topic == "" {
+		return INVALID_TOPIC
+	}
+
+	if actor == nil || !actor.IsActive() || actor.Writer() == nil || actor.Writer().IsClosed() {
+		return SUBSCRIPTION_NOT_SUPPORTED
+	}
+
+	server.topics.add(actor, topic)
+	return nil
+}
+
+func (server *baseServer) Unsubscribe(actor IActor, topic string) error {
+	if topic == "" {
+		return INVALID_TOPIC
+	}
+
+	server.topics.remove(actor, topic)
+	return nil
+}
+
+// Publish sends the payload to every subscriber of the topic, serializing it
+// only once the same way Broadcast does.
+func (server *baseServer) Publish(topic string, resultType uint64, payload Pointer) error {
+	if topic == "" {
+		return INVALID_TOPIC
+	}
+
+	if !reflection.IsPointer(payload) {
+		return errors.New("publish_failure: non_pointer_payload")
+	}
+
+	serializedPayload, err := server.serializeBroadcast(resultType, payload)
+	if err != nil {
+		return err
+	}
+
+	server.publishToBus(BUS_PUBLISH, topic, serializedPayload)
+	server.publish(topic, serializedPayload)
+	return nil
+}
+
+func (server *baseServer) publish(topic string, serializedPayload []byte) {
+	for _, actor := range server.topics.get(topic) {
+		if actor.Writer().IsOpen() {
+			actor.Writer().WriteBytes(serializedPayload)
+		} else {
+			server.OnSocketDisconnected(actor)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package server_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/service"
+	. "github.com/xeronith/diamante/contracts/system"
+	"github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server"
+	"github.com/xeronith/diamante/server/servertest"
+)
+
+// topicOperation runs commands such as subscribe:news, unsubscribe:news and
+// publish:news:message on behalf of its caller.
+type topicOperation struct {
+	operation.Operation
+}
+
+func (operation *topicOperation) Tag() string              { return "TOPIC" }
+func (operation *topicOperation) Id() (ID, ID)             { return 370, 371 }
+func (operation *topicOperation) InputContainer() Pointer  { return &protobuf.ServerError{} }
+func (operation *topicOperation) OutputContainer() Pointer { return &protobuf.ServerError{} }
+func (operation *topicOperation) Execute(context IContext, payload Pointer) (Pointer, error) {
+	arguments := append(strings.SplitN(payload.(*protobuf.ServerError).Message, ":", 3), "", "")
+
+	var err error
+	switch arguments[0] {
+	case "subscribe":
+		err = context.Subscribe(arguments[1])
+	case "unsubscribe":
+		err = context.Unsubscribe(arguments[1])
+	case "publish":
+		err = context.Publish(arguments[1], 371, &protobuf.ServerError{Message: arguments[2]})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &protobuf.ServerError{}, nil
+}
+
+func TestTopics(test *testing.T) {
+	harness := servertest.NewHarness(test, &topicOperation{})
+	harness.SetRole(370, ANONYMOUS)
+
+	run := func(actor *servertest.Actor, command string) error {
+		test.Helper()
+
+		return harness.Invoke(actor, 370, &protobuf.ServerError{Message: command}, &protobuf.ServerError{})
+	}
+
+	received := func(actor *servertest.Actor) []string {
+		test.Helper()
+
+		messages := make([]string, 0)
+		for _, push := range actor.Pushes() {
+			output := &protobuf.ServerError{}
+			if push.Type() != 371 || harness.Decode(push, output) != nil {
+				test.Fatal(push.Type())
+			}
+
+			messages = append(messages, output.Message)
+		}
+
+		actor.Reset()
+		return messages
+	}
+
+	first, second, third := harness.Connect(nil), harness.Connect(nil), harness.Connect(nil)
+	for _, actor := range []*servertest.Actor{first, second} {
+		if err := run(actor, "subscribe:news"); err != nil {
+			test.Fatal(err)
+		}
+	}
+
+	if err := run(third, "subscribe:sports"); err != nil {
+		test.Fatal(err)
+	}
+
+	// Request-response actors cannot subscribe, and topics must be named.
+	if err := run(harness.PassiveActor(nil), "subscribe:news"); err == nil || err.Error() != server.SUBSCRIPTION_NOT_SUPPORTED.Error() {
+		test.Fatal(err)
+	}
+
+	for _, command := range []string{"subscribe:", "unsubscribe:", "publish::message"} {
+		if err := run(first, command); err == nil || err.Error() != server.INVALID_TOPIC.Error() {
+			test.Fatal(command, err)
+		}
+	}
+
+	publish := func(message string) {
+		test.Helper()
+
+		if err := run(harness.PassiveActor(nil), "publish:news:"+message); err != nil {
+			test.Fatal(err)
+		}
+	}
+
+	test.Run("publish", func(test *testing.T) {
+		publish("first")
+		publish("second")
+
+		for _, actor := range []*servertest.Actor{first, second} {
+			if messages := received(actor); strings.Join(messages, ",") != "first,second" {
+				test.Fatal(messages)
+			}
+		}
+
+		if messages := received(third); len(messages) != 0 {
+			test.Fatal(messages)
+		}
+	})
+
+	test.Run("unsubscribe", func(test *testing.T) {
+		if err := run(first, "unsubscribe:news"); err != nil {
+			test.Fatal(err)
+		}
+
+		// Unsubscribing from a topic that has no subscription is harmless.
+		if err := run(third, "unsubscribe:news"); err != nil {
+			test.Fatal(err)
+		}
+
+		publish("unsubscribed")
+		if messages := received(first); len(messages) != 0 {
+			test.Fatal(messages)
+		}
+
+		if messages := received(second); len(messages) != 1 || messages[0] != "unsubscribed" {
+			test.Fatal(messages)
+		}
+	})
+
+	test.Run("disconnect", func(test *testing.T) {
+		// The server forgets the subscriptions of a disconnected actor even
+		// if its socket is still writable.
+		harness.OnSocketDisconnected(second)
+
+		publish("disconnected")
+		if messages := received(second); len(messages) != 0 {
+			test.Fatal(messages)
+		}
+
+		// Subscribing again after reconnecting works as before.
+		harness.OnSocketConnected(second)
+		if err := run(second, "subscribe:news"); err != nil {
+			test.Fatal(err)
+		}
+
+		publish("reconnected")
+		if messages := received(second); len(messages) != 1 || messages[0] != "reconnected" {
+			test.Fatal(messages)
+		}
+	})
+}
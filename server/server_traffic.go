@@ -0,0 +1,51 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/actor"
+	. "github.com/xeronith/diamante/contracts/io"
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/protobuf"
+)
+
+// recordTraffic appends the request, as received, and its result, as sent,
+// to the traffic recording when traffic recording is enabled. Requests that
+// fail to decode are recorded too, along with their error result. The token
+// is the one the request was authorized with, so that the recording can be
+// replayed by traffic.Replay.
+func (server *baseServer) recordTraffic(actor IActor, request []byte, result IOperationResult, timestamp time.Time) {
+	if server.trafficWriter == nil || result == nil || actor.Writer() == nil {
+		return
+	}
+
+	writer := actor.Writer()
+	resultData, err := actor.Serializer().Serialize(result.Container())
+	if err != nil {
+		server.logger.Error(fmt.Sprintf("TRAFFIC RECORD ERROR: %s", err))
+		return
+	}
+
+	token := writer.GetAuthCookie()
+	if token == "" {
+		token = actor.Token()
+	}
+
+	transport := ""
+	if transportWriter, ok := writer.(ITransportWriter); ok {
+		transport = transportWriter.Transport()
+	}
+
+	if err := server.trafficWriter.Write(&TrafficRecord{
+		Timestamp:   timestamp.UnixNano(),
+		Duration:    int64(time.Since(timestamp)),
+		Token:       token,
+		Transport:   transport,
+		ContentType: writer.ContentType(),
+		Request:     request,
+		Result:      resultData,
+	}); err != nil {
+		server.logger.Error(fmt.Sprintf("TRAFFIC RECORD ERROR: %s", err))
+	}
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/xeronith/diamante/contracts/server"
+)
+
+func (server *baseServer) RegisterTransport(transport ITransport) error {
+	if transport == nil {
+		return errors.New("nil transport")
+	}
+
+	if server.isRunning() {
+		return errors.New("not allowed to register transports when server is running")
+	}
+
+	for _, registered := range server.transports {
+		if registered.Name() == transport.Name() {
+			return fmt.Errorf("transport %s already registered", transport.Name())
+		}
+	}
+
+	server.transports = append(server.transports, transport)
+	return nil
+}
+
+func (server *baseServer) RegisterTransports(transports ...ITransport) error {
+	for _, transport := range transports {
+		if err := server.RegisterTransport(transport); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (server *defaultServer) startTransport(transport ITransport) {
+	if err := transport.Listen(server); err != nil && !server.IsShuttingDown() {
+		server.logger.Error(fmt.Sprintf("TRANSPORT %s FAILURE: %s", transport.Name(), err))
+	}
+}
+
+func (server *baseServer) closeTransports() {
+	for _, transport := range server.transports {
+		if err := transport.Close(); err != nil {
+			server.logger.Error(fmt.Sprintf("TRANSPORT %s CLOSE ERROR: %s", transport.Name(), err))
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/server"
+)
+
+// apiVersions returns the inclusive range of api versions served by an
+// operation. Operations that are not versioned serve every api version.
+func apiVersions(operation IOperation) (int32, int32) {
+	versioned, ok := operation.(IVersionedOperation)
+	if !ok {
+		return 0, math.MaxInt32
+	}
+
+	minimum, maximum := versioned.ApiVersions()
+	if maximum == 0 {
+		maximum = math.MaxInt32
+	}
+
+	return minimum, maximum
+}
+
+func (server *baseServer) registerVersion(opcode uint64, operation IOperation) error {
+	minimum, maximum := apiVersions(operation)
+	if minimum > maximum {
+		return fmt.Errorf("operation id %d has an invalid api version range %d-%d", opcode, minimum, maximum)
+	}
+
+	for _, existing := range server.versions[opcode] {
+		existingMinimum, existingMaximum := apiVersions(existing)
+		if minimum <= existingMaximum && existingMinimum <= maximum {
+			if _, ok := operation.(IVersionedOperation); !ok {
+				return fmt.Errorf("operation id %d already registered", opcode)
+			}
+
+			return fmt.Errorf("operation id %d already registered for api versions %d-%d", opcode, existingMinimum, existingMaximum)
+		}
+	}
+
+	server.versions[opcode] = append(server.versions[opcode], operation)
+	return nil
+}
+
+// resolveOperation picks the implementation of an opcode that serves the
+// requested api version. Requests that do not specify an api version are
+// served as the configured default api version or, without one, by the
+// original implementation, so that clients which predate an api version
+// are not handed its breaking changes.
+func (server *baseServer) resolveOperation(opcode uint64, apiVersion int32) IOperation {
+	if apiVersion == 0 {
+		apiVersion = server.configuration.GetServerConfiguration().GetClientsConfiguration().GetDefaultApiVersion()
+	}
+
+	var (
+		original        IOperation
+		originalMinimum int32
+	)
+
+	for _, operation := range server.versions[opcode] {
+		minimum, maximum := apiVersions(operation)
+		if apiVersion == 0 {
+			if original == nil || minimum < originalMinimum {
+				original, originalMinimum = operation, minimum
+			}
+		} else if apiVersion >= minimum && apiVersion <= maximum {
+			return operation
+		}
+	}
+
+	return original
+}
+
+// warnDeprecation logs the use of a deprecated api version once per opcode
+// and api version.
+func (server *baseServer) warnDeprecation(pipeline IPipeline) {
+	deprecated, sunset := pipeline.Deprecation()
+	if !deprecated {
+		return
+	}
+
+	key := fmt.Sprintf("%d:%d", pipeline.Opcode(), pipeline.ApiVersion())
+	if _, warned := server.deprecationWarnings.LoadOrStore(key, true); warned {
+		return
+	}
+
+	message := fmt.Sprintf("DEPRECATED 0x%.8X API VERSION %d %s", pipeline.Opcode(), pipeline.ApiVersion(), server.opcodes[pipeline.Opcode()])
+	if !sunset.IsZero() {
+		message = fmt.Sprintf("%s SUNSET %s", message, sunset.UTC().Format(time.RFC3339))
+	}
+
+	server.logger.Warning(message)
+}
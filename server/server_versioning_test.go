@@ -0,0 +1,81 @@
+package server_test
+
+import (
+	"testing"
+
+	. "github.com/xeronith/diamante/contracts/security"
+	"github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server"
+	"github.com/xeronith/diamante/server/servertest"
+	"github.com/xeronith/diamante/settings"
+)
+
+func TestVersioning(test *testing.T) {
+	harness := servertest.NewHarness(test,
+		&versionedOperation{minimum: 2, maximum: 2},
+		&versionedOperation{minimum: 1, maximum: 1},
+		&versionedOperation{minimum: 3, maximum: 4},
+	)
+
+	harness.SetRole(400, ANONYMOUS)
+
+	call := func(apiVersion int32) (int32, string) {
+		test.Helper()
+
+		actor := harness.PassiveActor(nil)
+		request := operation.CreateOperationRequest(1, 400, "", 0, apiVersion, "", nil)
+		if err := request.Load(&protobuf.ServerError{}, actor.Serializer()); err != nil {
+			test.Fatal(err)
+		}
+
+		data, err := actor.Serializer().Serialize(request.Container())
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		output := &protobuf.ServerError{}
+		result := harness.OnData(actor, data)
+		if result.Status() != server.OK {
+			return result.Status(), ""
+		}
+
+		if err := harness.Decode(result, output); err != nil {
+			test.Fatal(err)
+		}
+
+		return result.Status(), output.Message
+	}
+
+	for apiVersion, expected := range map[int32]string{1: "v1", 2: "v2", 3: "v3", 4: "v3"} {
+		if status, output := call(apiVersion); status != server.OK || output != expected {
+			test.Fatal(apiVersion, status, output)
+		}
+	}
+
+	if status, _ := call(5); status != server.BadRequest {
+		test.Fatal(status)
+	}
+
+	test.Run("original", func(test *testing.T) {
+		// Clients that predate versioning are not handed breaking changes.
+		if status, output := call(0); status != server.OK || output != "v1" {
+			test.Fatal(status, output)
+		}
+	})
+
+	test.Run("default", func(test *testing.T) {
+		clientsConfiguration := harness.Configuration().GetServerConfiguration().GetClientsConfiguration().(*settings.Clients)
+		defer func() { clientsConfiguration.DefaultApiVersion = 0 }()
+
+		clientsConfiguration.DefaultApiVersion = 4
+		if status, output := call(0); status != server.OK || output != "v3" {
+			test.Fatal(status, output)
+		}
+
+		clientsConfiguration.DefaultApiVersion = 5
+		if status, _ := call(0); status != server.BadRequest {
+			test.Fatal(status)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/actor"
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/server"
+)
+
+type frameDispatcher struct {
+	server     *baseServer
+	actor      IActor
+	concurrent chan *frame
+	sequential chan *frame
+	waitGroup  sync.WaitGroup
+}
+
+// frame is a decoded request queued along with its data, as received.
+type frame struct {
+	data    []byte
+	request IOperationRequest
+}
+
+func (server *baseServer) createFrameDispatcher(actor IActor, workers int) *frameDispatcher {
+	dispatcher := &frameDispatcher{
+		server:     server,
+		actor:      actor,
+		concurrent: make(chan *frame, workers),
+		sequential: make(chan *frame, workers),
+	}
+
+	dispatcher.waitGroup.Add(workers + 1)
+	for i := 0; i < workers; i++ {
+		go dispatcher.run(dispatcher.concurrent)
+	}
+
+	go dispatcher.run(dispatcher.sequential)
+
+	return dispatcher
+}
+
+func (dispatcher *frameDispatcher) Submit(data []byte) {
+	request, result := dispatcher.server.decode(dispatcher.actor, data)
+	if result != nil {
+		dispatcher.server.recordTraffic(dispatcher.actor, data, result, time.Now())
+		dispatcher.actor.Dispatch(result)
+		return
+	}
+
+	// Queued frames count as pending pipelines, so that a graceful shutdown
+	// waits for them too.
+	atomic.AddInt64(&dispatcher.server.pendingPipelines, 1)
+	if dispatcher.server.isSequential(request.Operation(), request.ApiVersion()) {
+		dispatcher.sequential <- &frame{data: data, request: request}
+	} else {
+		dispatcher.concurrent <- &frame{data: data, request: request}
+	}
+}
+
+func (dispatcher *frameDispatcher) Close() {
+	close(dispatcher.concurrent)
+	close(dispatcher.sequential)
+	dispatcher.waitGroup.Wait()
+}
+
+func (dispatcher *frameDispatcher) run(frames chan *frame) {
+	defer dispatcher.waitGroup.Done()
+
+	for frame := range frames {
+		timestamp := time.Now()
+		result := dispatcher.server.handleRequest(dispatcher.actor, frame.request)
+		dispatcher.server.recordTraffic(dispatcher.actor, frame.data, result, timestamp)
+		dispatcher.actor.Dispatch(result)
+		atomic.AddInt64(&dispatcher.server.pendingPipelines, -1)
+	}
+}
+
+func (server *baseServer) isSequential(opcode uint64, apiVersion int32) bool {
+	if opcode == SYSTEM_CALL_REQUEST {
+		return true
+	}
+
+	if operation, ok := server.resolveOperation(opcode, apiVersion).(ISequentialOperation); ok {
+		return operation.IsSequential()
+	}
+
+	return false
+}
@@ -0,0 +1,110 @@
+package server_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/system"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server/servertest"
+	"github.com/xeronith/diamante/settings"
+	"google.golang.org/protobuf/proto"
+)
+
+type sequentialOperation struct {
+	*blockingOperation
+}
+
+func (operation *sequentialOperation) Tag() string        { return "SEQUENTIAL_BLOCK" }
+func (operation *sequentialOperation) Id() (ID, ID)       { return 310, 311 }
+func (operation *sequentialOperation) IsSequential() bool { return true }
+
+type sequentialEchoOperation struct {
+	echoOperation
+}
+
+func (operation *sequentialEchoOperation) Tag() string        { return "SEQUENTIAL_ECHO" }
+func (operation *sequentialEchoOperation) Id() (ID, ID)       { return 320, 321 }
+func (operation *sequentialEchoOperation) IsSequential() bool { return true }
+
+func TestFrameDispatcher(test *testing.T) {
+	blocking := newBlockingOperation()
+	sequential := &sequentialOperation{newBlockingOperation()}
+	harness := servertest.NewHarness(test, &echoOperation{}, blocking, sequential, &sequentialEchoOperation{})
+	for _, opcode := range []uint64{100, 300, 310, 320} {
+		harness.SetRole(opcode, ANONYMOUS)
+	}
+
+	harness.Configuration().GetServerConfiguration().GetWebSocketConfiguration().(*settings.WebSocket).Workers = 4
+	harness.Serve()
+
+	connection, _, err := websocket.DefaultDialer.Dial(harness.ActiveEndpoint(), nil)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	defer connection.Close()
+
+	send := func(id, opcode uint64) {
+		payload, _ := proto.Marshal(&protobuf.ServerError{})
+		data, _ := proto.Marshal(&protobuf.OperationRequest{Id: id, Operation: opcode, Payload: payload})
+		if err := connection.WriteMessage(websocket.BinaryMessage, data); err != nil {
+			test.Fatal(err)
+		}
+	}
+
+	results := make(chan uint64, 16)
+	go func() {
+		for {
+			_, data, err := connection.ReadMessage()
+			if err != nil {
+				close(results)
+				return
+			}
+
+			result := &protobuf.OperationResult{}
+			if err := proto.Unmarshal(data, result); err == nil {
+				results <- result.Id
+			}
+		}
+	}()
+
+	receive := func(timeout time.Duration) uint64 {
+		select {
+		case id := <-results:
+			return id
+		case <-time.After(timeout):
+			return 0
+		}
+	}
+
+	// A slow request does not hold up the requests sent after it.
+	send(1, 300)
+	<-blocking.started
+	send(2, 100)
+	if id := receive(servertest.AWAIT_TIMEOUT); id != 2 {
+		test.Fatal(id)
+	}
+
+	close(blocking.release)
+	if id := receive(servertest.AWAIT_TIMEOUT); id != 1 {
+		test.Fatal(id)
+	}
+
+	// Sequential operations are served one at a time, in order.
+	send(3, 310)
+	<-sequential.started
+	send(4, 320)
+	if id := receive(time.Millisecond * 100); id != 0 {
+		test.Fatal("sequential request served out of order", id)
+	}
+
+	close(sequential.release)
+	for _, expected := range []uint64{3, 4} {
+		if id := receive(servertest.AWAIT_TIMEOUT); id != expected {
+			test.Fatal(id)
+		}
+	}
+}
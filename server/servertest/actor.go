@@ -0,0 +1,201 @@
+package servertest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/actor"
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/serialization"
+	. "github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/serialization"
+)
+
+// Actor is an actor whose writer records everything the server sends to it:
+// the results written to it, the pushes and broadcasts, and the signals.
+type Actor struct {
+	IActor
+	writer *writer
+}
+
+// Results returns the results written to the actor, such as the partial
+// results of streaming operations or the result it was disconnected with.
+func (actor *Actor) Results() []IOperationResult {
+	actor.writer.mutex.Lock()
+	defer actor.writer.mutex.Unlock()
+
+	return append([]IOperationResult(nil), actor.writer.results...)
+}
+
+// Pushes returns the pushes, broadcasts and published messages the actor
+// has received. Broadcasts are delivered asynchronously, see AwaitPushes.
+func (actor *Actor) Pushes() []IOperationResult {
+	actor.writer.mutex.Lock()
+	defer actor.writer.mutex.Unlock()
+
+	return append([]IOperationResult(nil), actor.writer.pushes...)
+}
+
+// AwaitPushes waits up to AWAIT_TIMEOUT for the actor to receive at least
+// the given number of pushes, and returns the ones it has received.
+func (actor *Actor) AwaitPushes(count int) []IOperationResult {
+	deadline := time.NewTimer(AWAIT_TIMEOUT)
+	defer deadline.Stop()
+
+	for {
+		pushes := actor.Pushes()
+		if len(pushes) >= count {
+			return pushes
+		}
+
+		select {
+		case <-actor.writer.pushed:
+		case <-deadline.C:
+			return pushes
+		}
+	}
+}
+
+func (actor *Actor) Signals() []byte {
+	actor.writer.mutex.Lock()
+	defer actor.writer.mutex.Unlock()
+
+	return append([]byte(nil), actor.writer.signals...)
+}
+
+// Reset forgets everything the actor has received so far.
+func (actor *Actor) Reset() {
+	actor.writer.mutex.Lock()
+	defer actor.writer.mutex.Unlock()
+
+	actor.writer.results = nil
+	actor.writer.pushes = nil
+	actor.writer.signals = nil
+}
+
+func (actor *Actor) IsClosed() bool {
+	return actor.writer.IsClosed()
+}
+
+type writer struct {
+	mutex      sync.Mutex
+	test       testing.TB
+	serializer ISerializer
+	cookies    map[string]string
+	authCookie string
+	results    []IOperationResult
+	pushes     []IOperationResult
+	signals    []byte
+	pushed     chan struct{}
+	closed     bool
+}
+
+func newWriter(test testing.TB) *writer {
+	return &writer{
+		test:       test,
+		serializer: serialization.NewProtobufSerializer(),
+		cookies:    make(map[string]string),
+		pushed:     make(chan struct{}, 1),
+	}
+}
+
+func (writer *writer) IsClosed() bool {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	return writer.closed
+}
+
+func (writer *writer) IsOpen() bool {
+	return !writer.IsClosed()
+}
+
+func (writer *writer) ContentType() string {
+	return "application/octet-stream"
+}
+
+func (writer *writer) Transport() string {
+	return "servertest"
+}
+
+func (writer *writer) SetSecureCookie(key, value string) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	writer.cookies[key] = value
+}
+
+func (writer *writer) GetSecureCookie(key string) string {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	return writer.cookies[key]
+}
+
+func (writer *writer) SetAuthCookie(token string) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	writer.authCookie = token
+}
+
+func (writer *writer) GetAuthCookie() string {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	return writer.authCookie
+}
+
+func (writer *writer) SetToken(_ string) {
+}
+
+func (writer *writer) Write(result IOperationResult) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	writer.results = append(writer.results, result)
+}
+
+func (writer *writer) WriteByte(code byte) error {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	writer.signals = append(writer.signals, code)
+	return nil
+}
+
+func (writer *writer) WriteBytes(data []byte) {
+	result := NewOperationResult()
+	if err := writer.serializer.Deserialize(data, result.Container()); err != nil {
+		// Pushes may be written from any goroutine of the server, where the
+		// test cannot be stopped, so the push is dropped and the test failed.
+		writer.test.Errorf("undecodable push: %s", err)
+		return
+	}
+
+	writer.mutex.Lock()
+	writer.pushes = append(writer.pushes, result)
+	writer.mutex.Unlock()
+
+	select {
+	case writer.pushed <- struct{}{}:
+	default:
+	}
+}
+
+func (writer *writer) End(result IOperationResult) {
+	writer.Write(result)
+	writer.Close()
+}
+
+func (writer *writer) Serializer() ISerializer {
+	return writer.serializer
+}
+
+func (writer *writer) Close() {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	writer.closed = true
+}
@@ -0,0 +1,149 @@
+package servertest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xeronith/diamante/utility"
+)
+
+type timer struct {
+	id       string
+	due      time.Time
+	interval time.Duration
+	callback func()
+	sequence uint64
+}
+
+// Clock is a scheduler whose time only moves when Advance is called. The
+// callbacks run on the goroutine of Advance, in the order they fall due, so
+// tests of scheduled work are deterministic.
+type Clock struct {
+	mutex    sync.Mutex
+	now      time.Time
+	timers   map[string]*timer
+	sequence uint64
+}
+
+func NewClock(now time.Time) *Clock {
+	return &Clock{
+		now:    now,
+		timers: make(map[string]*timer),
+	}
+}
+
+func (clock *Clock) Start() {
+}
+
+func (clock *Clock) Stop() {
+}
+
+func (clock *Clock) SetTimeout(callback func(), timeout time.Duration) string {
+	return clock.set(callback, timeout, 0)
+}
+
+// SetInterval schedules a recurring callback. Intervals shorter than a
+// millisecond run every millisecond.
+func (clock *Clock) SetInterval(callback func(), interval time.Duration) string {
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+
+	return clock.set(callback, interval, interval)
+}
+
+func (clock *Clock) Cancel(id string) {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	delete(clock.timers, id)
+}
+
+func (clock *Clock) Now() time.Time {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	return clock.now
+}
+
+// Pending returns the number of scheduled callbacks.
+func (clock *Clock) Pending() int {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	return len(clock.timers)
+}
+
+// Advance moves the clock forward by the duration and runs every callback
+// that falls due on the way, including the ones scheduled by the callbacks
+// themselves.
+func (clock *Clock) Advance(duration time.Duration) {
+	clock.mutex.Lock()
+	target := clock.now.Add(duration)
+	clock.mutex.Unlock()
+
+	for {
+		callback, ok := clock.next(target)
+		if !ok {
+			break
+		}
+
+		callback()
+	}
+
+	clock.mutex.Lock()
+	clock.now = target
+	clock.mutex.Unlock()
+}
+
+func (clock *Clock) next(target time.Time) (func(), bool) {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	due := make([]*timer, 0)
+	for _, timer := range clock.timers {
+		if !timer.due.After(target) {
+			due = append(due, timer)
+		}
+	}
+
+	if len(due) == 0 {
+		return nil, false
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].due.Equal(due[j].due) {
+			return due[i].sequence < due[j].sequence
+		}
+
+		return due[i].due.Before(due[j].due)
+	})
+
+	timer := due[0]
+	clock.now = timer.due
+	if timer.interval > 0 {
+		timer.due = timer.due.Add(timer.interval)
+	} else {
+		delete(clock.timers, timer.id)
+	}
+
+	return timer.callback, true
+}
+
+func (clock *Clock) set(callback func(), duration, interval time.Duration) string {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	clock.sequence++
+	timer := &timer{
+		id:       utility.GenerateUUID(),
+		due:      clock.now.Add(duration),
+		interval: interval,
+		callback: callback,
+		sequence: clock.sequence,
+	}
+
+	clock.timers[timer.id] = timer
+	return timer.id
+}
@@ -0,0 +1,290 @@
+// Package servertest drives the operations of a server in process, without
+// sockets unless asked to, so that handlers can be tested fast and
+// deterministically.
+package servertest
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/xeronith/diamante/actor"
+	. "github.com/xeronith/diamante/contracts/analytics"
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/server"
+	. "github.com/xeronith/diamante/contracts/settings"
+	. "github.com/xeronith/diamante/contracts/system"
+	. "github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/security"
+	"github.com/xeronith/diamante/server"
+	"github.com/xeronith/diamante/settings"
+	"github.com/xeronith/diamante/utility"
+)
+
+const (
+	REMOTE_ADDRESS = "127.0.0.1"
+	USER_AGENT     = "servertest"
+	AWAIT_TIMEOUT  = 5 * time.Second
+)
+
+var UNEXPECTED_RESULT_TYPE = errors.New("unexpected_result_type")
+
+type operationFactory []IOperation
+
+func (factory operationFactory) Operations() []IOperation {
+	return factory
+}
+
+// Harness is a server built with the test configuration, whose security
+// handler knows the identities created by NewIdentity, whose scheduler is a
+// fake clock and whose measurements are recorded in memory. It is not started
+// unless Serve is called: requests are handed to OnData directly.
+type Harness struct {
+	IServer
+	test       testing.TB
+	operations []IOperation
+	security   *securityHandler
+	recorder   *recorder
+	clock      *Clock
+	requestId  uint64
+}
+
+func NewHarness(test testing.TB, operations ...IOperation) *Harness {
+	test.Helper()
+
+	return NewHarnessWithConfiguration(test, settings.NewTestConfiguration(), operations...)
+}
+
+// NewHarnessWithConfiguration builds the harness on the given configuration,
+// for the tests of settings read when the server is created, such as the port
+// of the grpc transport.
+func NewHarnessWithConfiguration(test testing.TB, configuration IConfiguration, operations ...IOperation) *Harness {
+	test.Helper()
+
+	instance, err := server.New(configuration, operationFactory(operations), nil)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	harness := &Harness{
+		IServer:    instance,
+		test:       test,
+		operations: operations,
+		security:   newSecurityHandler(),
+		recorder:   &recorder{},
+		clock:      NewClock(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)),
+	}
+
+	instance.SetSecurityHandler(harness.security)
+	instance.SetMeasurementsProvider(harness.recorder)
+	instance.SetScheduler(harness.clock)
+
+	return harness
+}
+
+// Serve starts the server in the background, for the tests that go through
+// its sockets, and waits until its active and passive endpoints accept
+// connections, as well as its grpc endpoint when it has one. The server is
+// shut down at the end of the test. Settings read on start, such as those of
+// the websockets, can be changed before.
+func (harness *Harness) Serve() {
+	harness.test.Helper()
+
+	// The endpoints are resolved before starting, since settings are filled
+	// in with their defaults on first read.
+	hosts := make([]string, 0)
+	for _, endpoint := range []string{harness.ActiveEndpoint(), harness.PassiveEndpoint()} {
+		address, err := url.Parse(endpoint)
+		if err != nil {
+			harness.test.Fatal(err)
+		}
+
+		hosts = append(hosts, address.Host)
+	}
+
+	if endpoint := harness.GRPCEndpoint(); endpoint != "" {
+		hosts = append(hosts, endpoint)
+	}
+
+	go harness.Start()
+	harness.test.Cleanup(func() { _ = harness.Shutdown(context.Background()) })
+
+	deadline := time.Now().Add(AWAIT_TIMEOUT)
+	for _, host := range hosts {
+		for {
+			connection, err := net.Dial("tcp", host)
+			if err == nil {
+				_ = connection.Close()
+				break
+			}
+
+			if time.Now().After(deadline) {
+				harness.test.Fatal(err)
+			}
+
+			time.Sleep(time.Millisecond * 10)
+		}
+	}
+}
+
+func (harness *Harness) Clock() *Clock {
+	return harness.clock
+}
+
+// SetRole changes the role required by every version of the operation.
+func (harness *Harness) SetRole(opcode uint64, role Role) {
+	for _, operation := range harness.operations {
+		if id, _ := operation.Id(); id == opcode {
+			operation.SetRole(role)
+		}
+	}
+}
+
+// NewIdentity creates an identity with a unique token that the security
+// handler of the harness authenticates.
+func (harness *Harness) NewIdentity(id int64, role Role) Identity {
+	identity := &identity{
+		Identity: security.CreateDefaultIdentity(utility.GenerateUUID(), role, REMOTE_ADDRESS, USER_AGENT),
+		id:       id,
+	}
+
+	harness.security.put(identity)
+	return identity
+}
+
+// Connect creates an active actor, as if it had opened a websocket, so that
+// it receives pushes, broadcasts and published messages. The identity may be
+// nil for anonymous actors.
+func (harness *Harness) Connect(identity Identity) *Actor {
+	actor := harness.createActor(identity, true, "")
+	harness.OnSocketConnected(actor)
+	return actor
+}
+
+// PassiveActor creates an actor that is not connected, as if it had sent an
+// http request.
+func (harness *Harness) PassiveActor(identity Identity) *Actor {
+	return harness.createActor(identity, false, "")
+}
+
+// SignedActor creates a passive actor that sends the signature of a result
+// it holds, as http clients do with X-Request-Signature, and so is answered
+// from the cache when it can be.
+func (harness *Harness) SignedActor(identity Identity, signature string) *Actor {
+	return harness.createActor(identity, false, signature)
+}
+
+func (harness *Harness) createActor(identity Identity, active bool, signature string) *Actor {
+	writer := newWriter(harness.test)
+	actor := &Actor{
+		IActor: CreateActor(writer, active, signature, REMOTE_ADDRESS, USER_AGENT),
+		writer: writer,
+	}
+
+	if identity != nil {
+		actor.SetToken(identity.Token())
+		actor.SetIdentity(identity)
+	}
+
+	return actor
+}
+
+// Disconnect closes the socket of the actor.
+func (harness *Harness) Disconnect(actor *Actor) {
+	actor.writer.Close()
+	harness.OnSocketDisconnected(actor)
+}
+
+// Call sends a request that does not specify an api version on behalf of the
+// actor and returns its result. Such requests are served as the default api
+// version of the configuration, or by the original version of the operation.
+func (harness *Harness) Call(actor *Actor, opcode uint64, input Pointer) IOperationResult {
+	harness.test.Helper()
+
+	return harness.OnData(actor, harness.Request(actor, opcode, input))
+}
+
+// Request encodes a request that does not specify an api version on behalf of
+// the actor. It fails the test on errors, so it must be called from the
+// goroutine of the test; the data can then be handed to OnData from any other.
+func (harness *Harness) Request(actor *Actor, opcode uint64, input Pointer) []byte {
+	harness.test.Helper()
+
+	request := CreateOperationRequest(atomic.AddUint64(&harness.requestId, 1), opcode, "", 0, 0, actor.Token(), nil)
+	if err := request.Load(input, actor.Serializer()); err != nil {
+		harness.test.Fatal(err)
+	}
+
+	data, err := actor.Serializer().Serialize(request.Container())
+	if err != nil {
+		harness.test.Fatal(err)
+	}
+
+	return data
+}
+
+// Invoke calls the operation and loads its result into the output. Error
+// results are returned as errors with the description of the ServerError,
+// or its message when it has no description.
+func (harness *Harness) Invoke(actor *Actor, opcode uint64, input, output Pointer) error {
+	harness.test.Helper()
+
+	descriptor, exists := harness.OperationDescriptor(opcode, 0)
+	if !exists {
+		harness.test.Fatalf("unknown operation: %d", opcode)
+	}
+
+	result := harness.Call(actor, opcode, input)
+	if result.Type() != descriptor.ResultId() {
+		if result.Type() != server.ERROR {
+			return UNEXPECTED_RESULT_TYPE
+		}
+
+		serverError := &protobuf.ServerError{}
+		if err := harness.Decode(result, serverError); err != nil {
+			return err
+		}
+
+		if serverError.Description != "" {
+			return errors.New(serverError.Description)
+		} else {
+			return errors.New(serverError.Message)
+		}
+	}
+
+	return harness.Decode(result, output)
+}
+
+// Decode loads the payload of a result, or of a push, into the output.
+func (harness *Harness) Decode(result IOperationResult, output Pointer) error {
+	return harness.Serializers()["application/octet-stream"].Deserialize(result.Payload(), output)
+}
+
+// Measurements returns the recorded measurements of the name whose tags
+// include the given ones.
+func (harness *Harness) Measurements(name string, tags Tags) []Measurement {
+	return harness.recorder.find(name, tags)
+}
+
+// AssertMeasurement fails the test unless a measurement of the name whose
+// tags include the given ones has been recorded, and returns the last one.
+func (harness *Harness) AssertMeasurement(name string, tags Tags) Measurement {
+	harness.test.Helper()
+
+	measurements := harness.recorder.find(name, tags)
+	if len(measurements) == 0 {
+		harness.test.Fatalf("no %s measurement with tags %v in %v", name, tags, harness.recorder.names())
+	}
+
+	return measurements[len(measurements)-1]
+}
+
+func (harness *Harness) ResetMeasurements() {
+	harness.recorder.reset()
+}
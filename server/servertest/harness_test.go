@@ -0,0 +1,142 @@
+package servertest_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/analytics"
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/service"
+	. "github.com/xeronith/diamante/contracts/system"
+	"github.com/xeronith/diamante/messaging"
+	"github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+	. "github.com/xeronith/diamante/server/servertest"
+)
+
+type echoOperation struct {
+	operation.Operation
+}
+
+func (operation *echoOperation) Tag() string              { return "ECHO" }
+func (operation *echoOperation) Id() (ID, ID)             { return 100, 101 }
+func (operation *echoOperation) InputContainer() Pointer  { return &protobuf.ServerError{} }
+func (operation *echoOperation) OutputContainer() Pointer { return &protobuf.ServerError{} }
+func (operation *echoOperation) Execute(context IContext, payload Pointer) (Pointer, error) {
+	input := payload.(*protobuf.ServerError)
+	if err := context.Push(messaging.NewPushMessage(200, &protobuf.ServerError{Message: "pushed"})); err != nil {
+		return nil, err
+	}
+
+	if err := context.Broadcast(201, &protobuf.ServerError{Message: "broadcast"}); err != nil {
+		return nil, err
+	}
+
+	context.SetTimeout(func() {
+		_ = context.Broadcast(202, &protobuf.ServerError{Message: input.Message})
+	}, time.Minute)
+
+	context.SubmitMeasurement("echo", Tags{"user": context.Identity().Username()}, Fields{"value": 1})
+	return &protobuf.ServerError{Message: input.Message}, nil
+}
+
+func TestHarness(test *testing.T) {
+	harness := NewHarness(test, &echoOperation{})
+	harness.SetRole(100, USER)
+
+	user := harness.Connect(harness.NewIdentity(1, USER))
+	observer := harness.Connect(nil)
+
+	output := &protobuf.ServerError{}
+	if err := harness.Invoke(user, 100, &protobuf.ServerError{Message: "hello"}, output); err != nil {
+		test.Fatal(err)
+	}
+
+	if output.Message != "hello" || user.Identity().Id() != 1 {
+		test.Fatal(output.Message)
+	}
+
+	if pushes := user.AwaitPushes(2); len(pushes) != 2 || pushes[0].Type() != 200 || pushes[1].Type() != 201 {
+		test.Fatal(pushes)
+	}
+
+	if pushes := observer.AwaitPushes(1); len(pushes) != 1 || pushes[0].Type() != 201 {
+		test.Fatal(pushes)
+	}
+
+	harness.AssertMeasurement("echo", nil)
+	harness.AssertMeasurement("operations", Tags{"type": "f"})
+
+	observer.Reset()
+	harness.Clock().Advance(59 * time.Second)
+	if len(observer.Pushes()) != 0 {
+		test.Fatal("early timeout")
+	}
+
+	harness.Clock().Advance(time.Second)
+	pushes := observer.AwaitPushes(1)
+	if len(pushes) != 1 || pushes[0].Type() != 202 {
+		test.Fatal(pushes)
+	}
+
+	if err := harness.Decode(pushes[0], output); err != nil || output.Message != "hello" {
+		test.Fatal(err, output.Message)
+	}
+
+	harness.Disconnect(observer)
+	if err := harness.Invoke(observer, 100, &protobuf.ServerError{}, output); err == nil {
+		test.Fatal("anonymous actor authorized")
+	}
+
+	if err := harness.Invoke(harness.PassiveActor(harness.NewIdentity(2, ANONYMOUS)), 100, &protobuf.ServerError{}, output); err == nil {
+		test.Fatal("anonymous identity authorized")
+	}
+}
+
+func TestClock(test *testing.T) {
+	clock := NewClock(time.Unix(0, 0))
+
+	calls := make([]string, 0)
+	clock.SetTimeout(func() { calls = append(calls, "timeout") }, 3*time.Second)
+	id := clock.SetInterval(func() { calls = append(calls, "interval") }, 2*time.Second)
+	cancelled := clock.SetTimeout(func() { calls = append(calls, "cancelled") }, time.Second)
+	clock.Cancel(cancelled)
+
+	clock.Advance(4 * time.Second)
+	if len(calls) != 3 || calls[0] != "interval" || calls[1] != "timeout" || calls[2] != "interval" {
+		test.Fatal(calls)
+	}
+
+	clock.Cancel(id)
+	clock.Advance(time.Hour)
+	if len(calls) != 3 || clock.Pending() != 0 || !clock.Now().Equal(time.Unix(0, 0).Add(time.Hour+4*time.Second)) {
+		test.Fatal(calls, clock.Now())
+	}
+}
+
+type recordingTB struct {
+	testing.TB
+	mutex  sync.Mutex
+	errors []string
+}
+
+func (test *recordingTB) Errorf(format string, args ...interface{}) {
+	test.mutex.Lock()
+	defer test.mutex.Unlock()
+
+	test.errors = append(test.errors, fmt.Sprintf(format, args...))
+}
+
+func TestHarness_UndecodablePush(test *testing.T) {
+	recording := &recordingTB{TB: test}
+	harness := NewHarness(recording, &echoOperation{})
+
+	actor := harness.Connect(nil)
+	actor.Writer().WriteBytes([]byte{0xFF, 0xFF})
+
+	if len(recording.errors) != 1 || len(actor.Pushes()) != 0 {
+		test.Fatal(recording.errors, actor.Pushes())
+	}
+}
@@ -0,0 +1,81 @@
+package servertest
+
+import (
+	"sync"
+
+	. "github.com/xeronith/diamante/contracts/analytics"
+)
+
+type Measurement struct {
+	Name   string
+	Tags   Tags
+	Fields Fields
+}
+
+// recorder is a measurements provider that keeps the submitted measurements
+// in memory. Asynchronous submissions are recorded synchronously.
+type recorder struct {
+	mutex        sync.Mutex
+	measurements []Measurement
+}
+
+func (recorder *recorder) SubmitMeasurement(name string, tags Tags, fields Fields) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	recorder.measurements = append(recorder.measurements, Measurement{
+		Name:   name,
+		Tags:   tags,
+		Fields: fields,
+	})
+}
+
+func (recorder *recorder) SubmitMeasurementAsync(name string, tags Tags, fields Fields) {
+	recorder.SubmitMeasurement(name, tags, fields)
+}
+
+func (recorder *recorder) Flush() {
+}
+
+func (recorder *recorder) find(name string, tags Tags) []Measurement {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	result := make([]Measurement, 0)
+	for _, measurement := range recorder.measurements {
+		if measurement.Name == name && measurement.matches(tags) {
+			result = append(result, measurement)
+		}
+	}
+
+	return result
+}
+
+func (recorder *recorder) names() []string {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	names := make([]string, 0, len(recorder.measurements))
+	for _, measurement := range recorder.measurements {
+		names = append(names, measurement.Name)
+	}
+
+	return names
+}
+
+func (recorder *recorder) reset() {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	recorder.measurements = nil
+}
+
+func (measurement Measurement) matches(tags Tags) bool {
+	for key, value := range tags {
+		if measurement.Tags[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
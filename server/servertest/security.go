@@ -0,0 +1,111 @@
+package servertest
+
+import (
+	"sync"
+
+	. "github.com/xeronith/diamante/contracts/security"
+	"github.com/xeronith/diamante/security"
+)
+
+type identity struct {
+	Identity
+	id int64
+}
+
+func (identity *identity) Id() int64 {
+	return identity.id
+}
+
+// securityHandler authenticates the tokens of the identities created by the
+// harness. Unknown tokens are only accepted by anonymous operations.
+type securityHandler struct {
+	mutex          sync.RWMutex
+	identities     map[string]Identity
+	accessControls *accessControls
+}
+
+func newSecurityHandler() *securityHandler {
+	return &securityHandler{
+		identities:     make(map[string]Identity),
+		accessControls: &accessControls{values: make(map[uint64]uint64)},
+	}
+}
+
+func (handler *securityHandler) put(identity Identity) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	handler.identities[identity.Token()] = identity
+}
+
+func (handler *securityHandler) AccessControlHandler() IAccessControlHandler {
+	return handler.accessControls
+}
+
+func (handler *securityHandler) SetAccessControlHandler(_ IAccessControlHandler) {
+}
+
+func (handler *securityHandler) Validate(_ string, _ string) (string, error) {
+	return "", nil
+}
+
+func (handler *securityHandler) Verify(_ string, _ string) (string, uint64, error) {
+	return "", 0, nil
+}
+
+func (handler *securityHandler) RefreshTokenCache(_ Identity, _ string) error {
+	return nil
+}
+
+func (handler *securityHandler) Authenticate(token string, role Role, remoteAddress string, userAgent string) Identity {
+	handler.mutex.RLock()
+	identity, exists := handler.identities[token]
+	handler.mutex.RUnlock()
+
+	if !exists {
+		if role != ANONYMOUS {
+			return nil
+		}
+
+		return security.CreateDefaultIdentity(token, ANONYMOUS, remoteAddress, userAgent)
+	}
+
+	if !identity.IsInRole(role) {
+		return nil
+	}
+
+	return identity
+}
+
+func (handler *securityHandler) SignOut(identity Identity) error {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	delete(handler.identities, identity.Token())
+	return nil
+}
+
+type accessControls struct {
+	mutex  sync.RWMutex
+	values map[uint64]uint64
+}
+
+func (accessControls *accessControls) AddOrUpdateAccessControl(key uint64, value uint64, _ Identity) error {
+	accessControls.mutex.Lock()
+	defer accessControls.mutex.Unlock()
+
+	accessControls.values[key] = value
+	return nil
+}
+
+func (accessControls *accessControls) AccessControls() map[uint64]uint64 {
+	accessControls.mutex.RLock()
+	defer accessControls.mutex.RUnlock()
+
+	result := make(map[uint64]uint64, len(accessControls.values))
+	for key, value := range accessControls.values {
+		result[key] = value
+	}
+
+	return result
+}
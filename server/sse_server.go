@@ -0,0 +1,96 @@
+package server
+
+import (
+	gocontext "context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	. "github.com/xeronith/diamante/actor"
+	. "github.com/xeronith/diamante/contracts/actor"
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/io"
+)
+
+// SSE_HEARTBEAT_INTERVAL is the interval of the keep-alives of event streams
+// when the heartbeats of the websockets are disabled.
+const SSE_HEARTBEAT_INTERVAL = time.Second * 15
+
+type connectionContextKey struct{}
+
+// connectionContext makes the underlying connection of every request
+// available to the handlers that need to manage its deadlines.
+func connectionContext(ctx gocontext.Context, connection net.Conn) gocontext.Context {
+	return gocontext.WithValue(ctx, connectionContextKey{}, connection)
+}
+
+// eventsHandler registers the caller, authenticated by the auth cookie, as a
+// long-lived actor so broadcasts and pushes reach it as server-sent events.
+func (server *defaultServer) eventsHandler(ctx echo.Context) error {
+	connection, _ := ctx.Request().Context().Value(connectionContextKey{}).(net.Conn)
+
+	var actor IActor
+	closed := make(chan struct{})
+	writer := CreateSSEWriter(server, ctx, connection, server.secureCookie, func() {
+		server.OnSocketDisconnected(actor)
+		close(closed)
+	})
+
+	actor = CreateActor(
+		writer,
+		true,
+		"",
+		ctx.RealIP(),
+		ctx.Request().UserAgent(),
+	)
+
+	token := writer.GetAuthCookie()
+	if token == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, UNAUTHORIZED.Error())
+	}
+
+	identity := server.getSecurityHandler().Authenticate(
+		token,
+		USER,
+		actor.RemoteAddress(),
+		actor.UserAgent(),
+	)
+
+	if identity == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, UNAUTHORIZED.Error())
+	}
+
+	actor.SetToken(token)
+	actor.SetIdentity(identity)
+	actor.UpdateLastActivity()
+
+	server.OnSocketConnected(actor)
+	defer writer.Close()
+
+	// The first keep-alive sends the response headers so the client
+	// knows the stream is open before anything is broadcast.
+	writer.WriteBytes(nil)
+
+	interval := server.Configuration().GetServerConfiguration().GetWebSocketConfiguration().GetHeartbeatInterval()
+	if interval <= 0 {
+		interval = SSE_HEARTBEAT_INTERVAL
+	}
+
+	heartbeat := time.NewTicker(interval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return nil
+		case <-writer.Context().Done():
+			return nil
+		case <-heartbeat.C:
+			// Server-sent events flow one way, so the keep-alives that go
+			// through are the only sign the client is still there.
+			writer.WriteBytes(nil)
+			actor.UpdateLastActivity()
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package server_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/service"
+	. "github.com/xeronith/diamante/contracts/system"
+	"github.com/xeronith/diamante/messaging"
+	"github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/server"
+	"github.com/xeronith/diamante/server/servertest"
+	"github.com/xeronith/diamante/settings"
+	"google.golang.org/protobuf/proto"
+)
+
+// loginOperation sets the token in its input as the auth cookie.
+type loginOperation struct {
+	operation.Operation
+}
+
+func (operation *loginOperation) Tag() string              { return "LOGIN" }
+func (operation *loginOperation) Id() (ID, ID)             { return 360, 361 }
+func (operation *loginOperation) InputContainer() Pointer  { return &protobuf.ServerError{} }
+func (operation *loginOperation) OutputContainer() Pointer { return &protobuf.ServerError{} }
+func (operation *loginOperation) Execute(context IContext, payload Pointer) (Pointer, error) {
+	context.SetAuthCookie(payload.(*protobuf.ServerError).Message)
+	return &protobuf.ServerError{}, nil
+}
+
+func TestEvents(test *testing.T) {
+	// Auth cookies cannot be encoded without keys.
+	configuration := settings.NewTestConfiguration()
+	configuration.GetServerConfiguration().(*settings.Server).HashKey = strings.Repeat("h", 32)
+	configuration.GetServerConfiguration().(*settings.Server).BlockKey = strings.Repeat("b", 32)
+	configuration.GetServerConfiguration().GetWebSocketConfiguration().(*settings.WebSocket).HeartbeatInterval = "20ms"
+
+	harness := servertest.NewHarnessWithConfiguration(test, configuration, &loginOperation{})
+	harness.SetRole(360, ANONYMOUS)
+
+	disconnected := make(chan string, 1)
+	harness.OnActorDisconnected(func(token string) { disconnected <- token })
+	harness.Serve()
+
+	identity := harness.NewIdentity(1, USER)
+	payload, _ := proto.Marshal(&protobuf.ServerError{Message: identity.Token()})
+	data, _ := proto.Marshal(&protobuf.OperationRequest{Id: 1, Operation: 360, Payload: payload})
+	response, err := http.Post(harness.PassiveEndpoint(), "application/octet-stream", bytes.NewReader(data))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	result := &protobuf.OperationResult{}
+	if err := proto.Unmarshal(readAll(test, response), result); err != nil || result.Status != server.OK {
+		test.Fatal(err, result.Status)
+	}
+
+	cookies := response.Cookies()
+	if len(cookies) != 1 {
+		test.Fatal(cookies)
+	}
+
+	endpoint := strings.TrimSuffix(harness.PassiveEndpoint(), "/") + "/events"
+	open := func(cookies ...*http.Cookie) *http.Response {
+		test.Helper()
+
+		request, err := http.NewRequest(http.MethodGet, endpoint, nil)
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		for _, cookie := range cookies {
+			request.AddCookie(cookie)
+		}
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		return response
+	}
+
+	if response := open(); response.StatusCode != http.StatusUnauthorized {
+		test.Fatal(response.StatusCode)
+	}
+
+	response = open(cookies...)
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK || !strings.HasPrefix(response.Header.Get("Content-Type"), "text/event-stream") {
+		test.Fatal(response.StatusCode, response.Header.Get("Content-Type"))
+	}
+
+	// Events are separated by blank lines; keep-alives are empty comments.
+	events := make(chan string, 64)
+	go func() {
+		defer close(events)
+
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			if line := scanner.Text(); line != "" {
+				events <- line
+			}
+		}
+	}()
+
+	next := func() string {
+		test.Helper()
+
+		select {
+		case event, ok := <-events:
+			if !ok {
+				test.Fatal("stream closed")
+			}
+
+			return event
+		case <-time.After(servertest.AWAIT_TIMEOUT):
+			test.Fatal("no event received")
+			return ""
+		}
+	}
+
+	message := func() string {
+		test.Helper()
+
+		for {
+			event := next()
+			if event == ":" {
+				continue
+			}
+
+			data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(event, "data: "))
+			if err != nil {
+				test.Fatal(event, err)
+			}
+
+			result, output := &protobuf.OperationResult{}, &protobuf.ServerError{}
+			if err := proto.Unmarshal(data, result); err != nil || proto.Unmarshal(result.Payload, output) != nil {
+				test.Fatal(event, err)
+			}
+
+			return output.Message
+		}
+	}
+
+	// The stream opens with a keep-alive, and keeps sending them.
+	for count := 0; count < 3; count++ {
+		if event := next(); event != ":" {
+			test.Fatal(event)
+		}
+	}
+
+	if err := harness.Broadcast(101, &protobuf.ServerError{Message: "broadcast"}); err != nil {
+		test.Fatal(err)
+	}
+
+	if output := message(); output != "broadcast" {
+		test.Fatal(output)
+	}
+
+	if err := harness.PushToken(identity.Token(), messaging.NewPushMessage(101, &protobuf.ServerError{Message: "push"})); err != nil {
+		test.Fatal(err)
+	}
+
+	if output := message(); output != "push" {
+		test.Fatal(output)
+	}
+
+	// Closing the stream disconnects the actor.
+	_ = response.Body.Close()
+	select {
+	case token := <-disconnected:
+		if token != identity.Token() {
+			test.Fatal(token)
+		}
+	case <-time.After(servertest.AWAIT_TIMEOUT):
+		test.Fatal("not disconnected")
+	}
+}
+
+func readAll(test *testing.T, response *http.Response) []byte {
+	test.Helper()
+
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	return data
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jinzhu/configor"
 	. "github.com/xeronith/diamante/contracts/settings"
@@ -12,15 +13,23 @@ import (
 )
 
 type Server struct {
-	FQDN               string `yaml:"fqdn"`
-	Protocol           string `yaml:"protocol"`
-	Ports              *Ports `yaml:"ports"`
-	TLS                *TLS   `yaml:"tls"`
-	BuildNumber        int32  `yaml:"build_number"`
-	JwtTokenKey        string `yaml:"jwt_token_key"`
-	JwtTokenExpiration string `yaml:"jwt_token_expiration"`
-	HashKey            string `yaml:"hash_key"`
-	BlockKey           string `yaml:"block_key"`
+	FQDN               string      `yaml:"fqdn"`
+	Protocol           string      `yaml:"protocol"`
+	Ports              *Ports      `yaml:"ports"`
+	TLS                *TLS        `yaml:"tls"`
+	WebSocket          *WebSocket  `yaml:"websocket"`
+	Cache              *Cache      `yaml:"cache"`
+	Cluster            *Cluster    `yaml:"cluster"`
+	Traffic            *Traffic    `yaml:"traffic"`
+	RequestLog         *RequestLog `yaml:"request_log"`
+	Batch              *Batch      `yaml:"batch"`
+	Streaming          *Streaming  `yaml:"streaming"`
+	Clients            *Clients    `yaml:"clients"`
+	BuildNumber        int32       `yaml:"build_number"`
+	JwtTokenKey        string      `yaml:"jwt_token_key"`
+	JwtTokenExpiration string      `yaml:"jwt_token_expiration"`
+	HashKey            string      `yaml:"hash_key"`
+	BlockKey           string      `yaml:"block_key"`
 }
 
 func (server *Server) GetFQDN() string {
@@ -63,6 +72,105 @@ func (server *Server) GetTLSConfiguration() ITLSConfiguration {
 	return server.TLS
 }
 
+func (server *Server) GetWebSocketConfiguration() IWebSocketConfiguration {
+	if server.WebSocket == nil {
+		server.WebSocket = &WebSocket{
+			Workers:               1,
+			HeartbeatInterval:     "",
+			IdleTimeout:           "",
+			ReadBufferSize:        0,
+			WriteBufferSize:       0,
+			MaxMessageSize:        0,
+			Compression:           false,
+			CompressionLevel:      0,
+			CompressionThreshold:  0,
+			ResumptionGracePeriod: "",
+			ResumptionQueueSize:   0,
+		}
+	}
+
+	return server.WebSocket
+}
+
+func (server *Server) GetCacheConfiguration() ICacheConfiguration {
+	if server.Cache == nil {
+		server.Cache = &Cache{
+			Capacity: 1000,
+			TTL:      "",
+		}
+	}
+
+	return server.Cache
+}
+
+func (server *Server) GetClusterConfiguration() IClusterConfiguration {
+	if server.Cluster == nil {
+		server.Cluster = &Cluster{
+			Address: "",
+			Secret:  "",
+			Peers:   []string{},
+		}
+	}
+
+	return server.Cluster
+}
+
+func (server *Server) GetTrafficConfiguration() ITrafficConfiguration {
+	if server.Traffic == nil {
+		server.Traffic = &Traffic{
+			Path:        "",
+			MaxFileSize: 0,
+			MaxFiles:    0,
+		}
+	}
+
+	return server.Traffic
+}
+
+func (server *Server) GetRequestLogConfiguration() IRequestLogConfiguration {
+	if server.RequestLog == nil {
+		server.RequestLog = &RequestLog{
+			Format:         "",
+			SampleRate:     0,
+			RedactedFields: []string{},
+			Path:           "",
+		}
+	}
+
+	return server.RequestLog
+}
+
+func (server *Server) GetBatchConfiguration() IBatchConfiguration {
+	if server.Batch == nil {
+		server.Batch = &Batch{
+			MaxSize: 0,
+			Workers: 0,
+		}
+	}
+
+	return server.Batch
+}
+
+func (server *Server) GetStreamingConfiguration() IStreamingConfiguration {
+	if server.Streaming == nil {
+		server.Streaming = &Streaming{
+			Timeout: "",
+		}
+	}
+
+	return server.Streaming
+}
+
+func (server *Server) GetClientsConfiguration() IClientsConfiguration {
+	if server.Clients == nil {
+		server.Clients = &Clients{
+			RejectAnonymous: false,
+		}
+	}
+
+	return server.Clients
+}
+
 func (server *Server) GetBuildNumber() int32 {
 	return server.BuildNumber
 }
@@ -97,6 +205,7 @@ type Ports struct {
 	Active      int `yaml:"active"`
 	Passive     int `yaml:"passive"`
 	Diagnostics int `yaml:"diagnostics"`
+	GRPC        int `yaml:"grpc"`
 }
 
 func (ports *Ports) GetActive() int {
@@ -111,6 +220,11 @@ func (ports *Ports) GetDiagnostics() int {
 	return ports.Diagnostics
 }
 
+// GetGRPC returns the port of the grpc transport, which is disabled when zero.
+func (ports *Ports) GetGRPC() int {
+	return ports.GRPC
+}
+
 //------------------------------------------------------------------------------------------------------------
 
 type TLS struct {
@@ -132,6 +246,329 @@ func (tls *TLS) GetCertFile() string {
 
 //------------------------------------------------------------------------------------------------------------
 
+type WebSocket struct {
+	Workers               int    `yaml:"workers"`
+	HeartbeatInterval     string `yaml:"heartbeat_interval"`
+	IdleTimeout           string `yaml:"idle_timeout"`
+	ReadBufferSize        int    `yaml:"read_buffer_size"`
+	WriteBufferSize       int    `yaml:"write_buffer_size"`
+	MaxMessageSize        int64  `yaml:"max_message_size"`
+	Compression           bool   `yaml:"compression"`
+	CompressionLevel      int    `yaml:"compression_level"`
+	CompressionThreshold  int    `yaml:"compression_threshold"`
+	ResumptionGracePeriod string `yaml:"resumption_grace_period"`
+	ResumptionQueueSize   int    `yaml:"resumption_queue_size"`
+}
+
+func (webSocket *WebSocket) GetWorkers() int {
+	if webSocket.Workers < 1 {
+		return 1
+	}
+
+	return webSocket.Workers
+}
+
+// GetHeartbeatInterval returns the interval of the pings sent to idle sockets.
+// Sockets that don't answer within two intervals are closed. Heartbeats are
+// disabled when empty or zero. Event streams send their keep-alives at the
+// same interval, or every 15 seconds when heartbeats are disabled.
+func (webSocket *WebSocket) GetHeartbeatInterval() time.Duration {
+	interval, err := time.ParseDuration(strings.TrimSpace(webSocket.HeartbeatInterval))
+	if err != nil || interval < 0 {
+		return 0
+	}
+
+	return interval
+}
+
+// GetIdleTimeout returns how long a connection may go without any activity,
+// be it a frame, a pong or an event stream keep-alive, before it is reaped.
+// Zero, the default, keeps idle connections open.
+func (webSocket *WebSocket) GetIdleTimeout() time.Duration {
+	timeout, err := time.ParseDuration(strings.TrimSpace(webSocket.IdleTimeout))
+	if err != nil || timeout < 0 {
+		return 0
+	}
+
+	return timeout
+}
+
+// GetReadBufferSize returns the size of the read buffer of every socket in
+// bytes. Zero leaves the choice to the websocket library.
+func (webSocket *WebSocket) GetReadBufferSize() int {
+	if webSocket.ReadBufferSize < 0 {
+		return 0
+	}
+
+	return webSocket.ReadBufferSize
+}
+
+func (webSocket *WebSocket) GetWriteBufferSize() int {
+	if webSocket.WriteBufferSize < 0 {
+		return 0
+	}
+
+	return webSocket.WriteBufferSize
+}
+
+// GetMaxMessageSize returns the largest inbound message in bytes, once
+// inflated. Sockets that exceed it are closed with the 1009 close code.
+// Zero, the default, removes the limit.
+func (webSocket *WebSocket) GetMaxMessageSize() int64 {
+	if webSocket.MaxMessageSize < 0 {
+		return 0
+	}
+
+	return webSocket.MaxMessageSize
+}
+
+// IsCompressionEnabled reports whether the permessage-deflate extension is
+// negotiated with the clients that support it.
+func (webSocket *WebSocket) IsCompressionEnabled() bool {
+	return webSocket.Compression
+}
+
+// GetCompressionLevel returns the flate compression level, from -2 (huffman
+// only) to 9 (best compression). Zero or an invalid level means 1 (best speed).
+func (webSocket *WebSocket) GetCompressionLevel() int {
+	if webSocket.CompressionLevel == 0 || webSocket.CompressionLevel < -2 || webSocket.CompressionLevel > 9 {
+		return 1
+	}
+
+	return webSocket.CompressionLevel
+}
+
+// GetCompressionThreshold returns the size in bytes below which outbound
+// messages are sent uncompressed, 512 by default.
+func (webSocket *WebSocket) GetCompressionThreshold() int {
+	if webSocket.CompressionThreshold <= 0 {
+		return 512
+	}
+
+	return webSocket.CompressionThreshold
+}
+
+// GetResumptionGracePeriod returns how long the state of a dropped socket is
+// kept for the client to resume it. Zero, the default, disables resumption.
+func (webSocket *WebSocket) GetResumptionGracePeriod() time.Duration {
+	gracePeriod, err := time.ParseDuration(strings.TrimSpace(webSocket.ResumptionGracePeriod))
+	if err != nil || gracePeriod < 0 {
+		return 0
+	}
+
+	return gracePeriod
+}
+
+// GetResumptionQueueSize returns how many outbound messages are kept for a
+// dropped socket, 256 by default. Sessions that miss more are not resumable.
+func (webSocket *WebSocket) GetResumptionQueueSize() int {
+	if webSocket.ResumptionQueueSize < 1 {
+		return 256
+	}
+
+	return webSocket.ResumptionQueueSize
+}
+
+//------------------------------------------------------------------------------------------------------------
+
+type Cache struct {
+	Capacity int    `yaml:"capacity"`
+	TTL      string `yaml:"ttl"`
+}
+
+func (cache *Cache) GetCapacity() int {
+	if cache.Capacity < 1 {
+		return 1000
+	}
+
+	return cache.Capacity
+}
+
+// GetTTL returns the default lifetime of cached results. Zero means results
+// stay cached until they are evicted or invalidated.
+func (cache *Cache) GetTTL() time.Duration {
+	ttl, err := time.ParseDuration(strings.TrimSpace(cache.TTL))
+	if err != nil || ttl < 0 {
+		return 0
+	}
+
+	return ttl
+}
+
+//------------------------------------------------------------------------------------------------------------
+
+type Cluster struct {
+	Address string   `yaml:"address"`
+	Secret  string   `yaml:"secret"`
+	Peers   []string `yaml:"peers"`
+}
+
+func (cluster *Cluster) IsEnabled() bool {
+	return cluster.Address != "" && len(cluster.Peers) > 0
+}
+
+// GetAddress returns the address this node listens on for the messages
+// of its peers, in host:port form.
+func (cluster *Cluster) GetAddress() string {
+	return cluster.Address
+}
+
+// GetSecret returns the secret the nodes of the cluster share to prove to
+// each other that they are members. The bus is not started without one.
+func (cluster *Cluster) GetSecret() string {
+	return cluster.Secret
+}
+
+func (cluster *Cluster) GetPeers() []string {
+	return cluster.Peers
+}
+
+//------------------------------------------------------------------------------------------------------------
+
+type Traffic struct {
+	Path        string `yaml:"path"`
+	MaxFileSize int64  `yaml:"max_file_size"`
+	MaxFiles    int    `yaml:"max_files"`
+}
+
+// GetPath returns the file the traffic is recorded to when traffic recording
+// is enabled. Rotated files get a numeric suffix, the most recent being .1.
+func (traffic *Traffic) GetPath() string {
+	if strings.TrimSpace(traffic.Path) == "" {
+		return "traffic.rec"
+	}
+
+	return traffic.Path
+}
+
+// GetMaxFileSize returns the size in bytes at which the recording is rotated.
+func (traffic *Traffic) GetMaxFileSize() int64 {
+	if traffic.MaxFileSize <= 0 {
+		return 64 * 1024 * 1024
+	}
+
+	return traffic.MaxFileSize
+}
+
+// GetMaxFiles returns the number of files kept, including the current one.
+func (traffic *Traffic) GetMaxFiles() int {
+	if traffic.MaxFiles < 1 {
+		return 5
+	}
+
+	return traffic.MaxFiles
+}
+
+//------------------------------------------------------------------------------------------------------------
+
+type RequestLog struct {
+	Format         string   `yaml:"format"`
+	SampleRate     float64  `yaml:"sample_rate"`
+	RedactedFields []string `yaml:"redacted_fields"`
+	Path           string   `yaml:"path"`
+}
+
+// GetFormat returns either json, the default, or logfmt.
+func (requestLog *RequestLog) GetFormat() string {
+	if strings.ToLower(strings.TrimSpace(requestLog.Format)) == "logfmt" {
+		return "logfmt"
+	}
+
+	return "json"
+}
+
+// GetSampleRate returns the fraction of the successful requests that are
+// logged. Failed requests are always logged.
+func (requestLog *RequestLog) GetSampleRate() float64 {
+	if requestLog.SampleRate <= 0 || requestLog.SampleRate > 1 {
+		return 1
+	}
+
+	return requestLog.SampleRate
+}
+
+// GetRedactedFields returns the fields of the request log whose values are
+// replaced with a placeholder, such as ip or user_agent.
+func (requestLog *RequestLog) GetRedactedFields() []string {
+	return requestLog.RedactedFields
+}
+
+// GetPath returns the file the request log is appended to. The request log
+// goes to the logger of the server when empty.
+func (requestLog *RequestLog) GetPath() string {
+	return strings.TrimSpace(requestLog.Path)
+}
+
+//------------------------------------------------------------------------------------------------------------
+
+type Batch struct {
+	MaxSize int `yaml:"max_size"`
+	Workers int `yaml:"workers"`
+}
+
+// GetMaxSize returns the number of requests a batch may carry.
+func (batch *Batch) GetMaxSize() int {
+	if batch.MaxSize < 1 {
+		return 50
+	}
+
+	return batch.MaxSize
+}
+
+// GetWorkers returns the number of requests of a concurrent batch that run
+// at the same time.
+func (batch *Batch) GetWorkers() int {
+	if batch.Workers < 1 {
+		return 8
+	}
+
+	return batch.Workers
+}
+
+//------------------------------------------------------------------------------------------------------------
+
+type Streaming struct {
+	Timeout string `yaml:"timeout"`
+}
+
+// GetTimeout returns how long a streaming operation may run before it is
+// cancelled. Zero, the default, lets streams run until they complete.
+func (streaming *Streaming) GetTimeout() time.Duration {
+	timeout, err := time.ParseDuration(strings.TrimSpace(streaming.Timeout))
+	if err != nil || timeout < 0 {
+		return 0
+	}
+
+	return timeout
+}
+
+//------------------------------------------------------------------------------------------------------------
+
+type Clients struct {
+	RejectAnonymous   bool  `yaml:"reject_anonymous"`
+	DefaultApiVersion int32 `yaml:"default_api_version"`
+}
+
+// IsAnonymousRejected reports whether requests that do not name their client
+// are answered with UpgradeRequired. They are accepted by default, in which
+// case the minimum client versions do not apply to them.
+func (clients *Clients) IsAnonymousRejected() bool {
+	return clients.RejectAnonymous
+}
+
+// GetDefaultApiVersion returns the api version that serves requests which do
+// not specify one. Zero, the default, serves them with the original version
+// of each operation.
+func (clients *Clients) GetDefaultApiVersion() int32 {
+	if clients.DefaultApiVersion < 0 {
+		return 0
+	}
+
+	return clients.DefaultApiVersion
+}
+
+//------------------------------------------------------------------------------------------------------------
+
 type PostgreSQL struct {
 	Host     string `yaml:"host"`
 	Port     string `yaml:"port"`
@@ -0,0 +1,89 @@
+package throttling
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/throttling"
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+type memoryRateLimitStore struct {
+	sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryRateLimitStore() IRateLimitStore {
+	return &memoryRateLimitStore{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (store *memoryRateLimitStore) Take(key string, rate float64, burst int) (bool, time.Duration) {
+	if rate <= 0 || burst < 1 {
+		return false, 0
+	}
+
+	now := time.Now()
+
+	store.Lock()
+	defer store.Unlock()
+
+	store.sweep(now)
+
+	_bucket, exists := store.buckets[key]
+	if !exists {
+		_bucket = &bucket{
+			tokens:  float64(burst),
+			updated: now,
+		}
+
+		store.buckets[key] = _bucket
+	}
+
+	elapsed := now.Sub(_bucket.updated).Seconds()
+	_bucket.tokens = math.Min(float64(burst), _bucket.tokens+elapsed*rate)
+	_bucket.updated = now
+
+	allowed := false
+	if _bucket.tokens >= 1 {
+		_bucket.tokens--
+		allowed = true
+	}
+
+	_bucket.full = now.Add(time.Duration((float64(burst) - _bucket.tokens) / rate * float64(time.Second)))
+
+	if allowed {
+		return true, 0
+	}
+
+	return false, time.Duration((1 - _bucket.tokens) / rate * float64(time.Second))
+}
+
+func (store *memoryRateLimitStore) Reset(key string) {
+	store.Lock()
+	defer store.Unlock()
+
+	delete(store.buckets, key)
+}
+
+func (store *memoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(store.lastSweep) < time.Minute {
+		return
+	}
+
+	store.lastSweep = now
+	for key, _bucket := range store.buckets {
+		if now.After(_bucket.full) {
+			delete(store.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package throttling
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/throttling"
+)
+
+type rateLimit struct {
+	rate  float64
+	burst int
+}
+
+func (limit *rateLimit) Rate() float64 {
+	return limit.rate
+}
+
+func (limit *rateLimit) Burst() int {
+	return limit.burst
+}
+
+type rateLimitKey struct {
+	opcode uint64
+	role   Role
+}
+
+type rateLimiter struct {
+	sync.RWMutex
+	store  IRateLimitStore
+	limits map[rateLimitKey]IRateLimit
+}
+
+func NewRateLimiter(store IRateLimitStore) IRateLimiter {
+	if store == nil {
+		store = NewMemoryRateLimitStore()
+	}
+
+	return &rateLimiter{
+		store:  store,
+		limits: make(map[rateLimitKey]IRateLimit),
+	}
+}
+
+func (limiter *rateLimiter) SetLimit(opcode uint64, role Role, rate float64, burst int) {
+	limiter.Lock()
+	defer limiter.Unlock()
+
+	limiter.limits[rateLimitKey{opcode, role}] = &rateLimit{
+		rate:  rate,
+		burst: burst,
+	}
+}
+
+func (limiter *rateLimiter) RemoveLimit(opcode uint64, role Role) {
+	limiter.Lock()
+	defer limiter.Unlock()
+
+	delete(limiter.limits, rateLimitKey{opcode, role})
+}
+
+// Limit resolves the most specific limit for the given opcode and role,
+// falling back to any-role and then any-opcode entries.
+func (limiter *rateLimiter) Limit(opcode uint64, role Role) (IRateLimit, bool) {
+	limiter.RLock()
+	defer limiter.RUnlock()
+
+	for _, key := range []rateLimitKey{
+		{opcode, role},
+		{opcode, ANY_ROLE},
+		{ANY_OPCODE, role},
+		{ANY_OPCODE, ANY_ROLE},
+	} {
+		if limit, exists := limiter.limits[key]; exists {
+			return limit, true
+		}
+	}
+
+	return nil, false
+}
+
+func (limiter *rateLimiter) Allow(opcode uint64, role Role, subject string) (bool, time.Duration) {
+	limit, exists := limiter.Limit(opcode, role)
+	if !exists {
+		return true, 0
+	}
+
+	return limiter.store.Take(fmt.Sprintf("%x:%s", opcode, subject), limit.Rate(), limit.Burst())
+}
+
+func (limiter *rateLimiter) Store() IRateLimitStore {
+	return limiter.store
+}
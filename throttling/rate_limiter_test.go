@@ -0,0 +1,63 @@
+package throttling_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/xeronith/diamante/contracts/security"
+	. "github.com/xeronith/diamante/contracts/throttling"
+	. "github.com/xeronith/diamante/throttling"
+)
+
+func TestRateLimiter_Allow(test *testing.T) {
+	limiter := NewRateLimiter(NewMemoryRateLimitStore())
+	limiter.SetLimit(100, ANY_ROLE, 50, 2)
+
+	for i := 0; i < 2; i++ {
+		if allowed, _ := limiter.Allow(100, USER, "subject"); !allowed {
+			test.FailNow()
+		}
+	}
+
+	allowed, retryAfter := limiter.Allow(100, USER, "subject")
+	if allowed || retryAfter <= 0 || retryAfter > 20*time.Millisecond {
+		test.FailNow()
+	}
+
+	if allowed, _ := limiter.Allow(100, USER, "another_subject"); !allowed {
+		test.FailNow()
+	}
+
+	if allowed, _ := limiter.Allow(200, USER, "subject"); !allowed {
+		test.FailNow()
+	}
+
+	time.Sleep(retryAfter + 5*time.Millisecond)
+	if allowed, _ := limiter.Allow(100, USER, "subject"); !allowed {
+		test.Fail()
+	}
+}
+
+func TestRateLimiter_Limit(test *testing.T) {
+	limiter := NewRateLimiter(nil)
+	limiter.SetLimit(ANY_OPCODE, ANY_ROLE, 1, 1)
+	limiter.SetLimit(100, ANY_ROLE, 2, 2)
+	limiter.SetLimit(100, ADMINISTRATOR, 3, 3)
+
+	if limit, _ := limiter.Limit(100, ADMINISTRATOR); limit.Burst() != 3 {
+		test.Fail()
+	}
+
+	if limit, _ := limiter.Limit(100, USER); limit.Burst() != 2 {
+		test.Fail()
+	}
+
+	if limit, _ := limiter.Limit(200, USER); limit.Burst() != 1 {
+		test.Fail()
+	}
+
+	limiter.RemoveLimit(ANY_OPCODE, ANY_ROLE)
+	if _, exists := limiter.Limit(200, USER); exists {
+		test.Fail()
+	}
+}
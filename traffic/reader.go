@@ -0,0 +1,61 @@
+package traffic
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/xeronith/diamante/protobuf"
+	"google.golang.org/protobuf/proto"
+)
+
+const MAX_RECORD_SIZE = 64 * 1024 * 1024
+
+var (
+	RECORD_TOO_LARGE = errors.New("record_too_large")
+	TRUNCATED_RECORD = errors.New("truncated_record")
+)
+
+// Read calls the handler with every record of the file, in the order they
+// were written, and stops at the first error the handler returns.
+func Read(path string, handler func(*protobuf.TrafficRecord) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = file.Close() }()
+
+	reader := bufio.NewReader(file)
+	var header [4]byte
+	for {
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return TRUNCATED_RECORD
+		}
+
+		size := binary.BigEndian.Uint32(header[:])
+		if size > MAX_RECORD_SIZE {
+			return RECORD_TOO_LARGE
+		}
+
+		data := make([]byte, size)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return TRUNCATED_RECORD
+		}
+
+		record := &protobuf.TrafficRecord{}
+		if err := proto.Unmarshal(data, record); err != nil {
+			return err
+		}
+
+		if err := handler(record); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package traffic
+
+import (
+	"bytes"
+	"fmt"
+
+	. "github.com/xeronith/diamante/actor"
+	. "github.com/xeronith/diamante/contracts/actor"
+	. "github.com/xeronith/diamante/contracts/operation"
+	. "github.com/xeronith/diamante/contracts/serialization"
+	. "github.com/xeronith/diamante/contracts/server"
+	"github.com/xeronith/diamante/protobuf"
+)
+
+// Difference is a recorded result that does not match the result of its
+// replayed request.
+type Difference struct {
+	Index     int
+	Operation uint64
+	Record    *protobuf.TrafficRecord
+	Expected  *protobuf.OperationResult
+	Actual    *protobuf.OperationResult
+}
+
+func (difference *Difference) String() string {
+	return fmt.Sprintf(
+		"#%d 0x%.8X: expected status %d type %d with %d byte(s), got status %d type %d with %d byte(s)",
+		difference.Index,
+		difference.Operation,
+		difference.Expected.Status,
+		difference.Expected.Type,
+		len(difference.Expected.Payload),
+		difference.Actual.Status,
+		difference.Actual.Type,
+		len(difference.Actual.Payload),
+	)
+}
+
+// Replay feeds the requests of the recording to the OnData of the server and
+// returns the results whose id, status, type or payload differ from the
+// recorded ones. The requests of a token share one actor, which carries the
+// token in place of the original auth cookie, so the security handler of the
+// server must accept the recorded tokens. Only the final results are
+// compared: pushes and the partial results of streams are ignored.
+func Replay(server IServer, path string) ([]*Difference, error) {
+	actors := make(map[string]IActor)
+	differences := make([]*Difference, 0)
+
+	index := 0
+	err := Read(path, func(record *protobuf.TrafficRecord) error {
+		defer func() { index++ }()
+
+		serializer, exists := server.Serializers()[record.ContentType]
+		if !exists {
+			serializer = server.Serializers()["application/octet-stream"]
+		}
+
+		key := record.Token + "\x00" + record.ContentType
+		actor, exists := actors[key]
+		if !exists {
+			actor = CreateActor(&replayWriter{record: record, serializer: serializer}, false, "", "", "")
+			actors[key] = actor
+		}
+
+		// Requests that failed to decode are recorded too, and replayed to
+		// check that they still fail the same way.
+		request := &protobuf.OperationRequest{}
+		_ = serializer.Deserialize(record.Request, request)
+
+		expected := &protobuf.OperationResult{}
+		if err := serializer.Deserialize(record.Result, expected); err != nil {
+			return err
+		}
+
+		actual := &protobuf.OperationResult{}
+		if result := server.OnData(actor, record.Request); result != nil {
+			data, err := serializer.Serialize(result.Container())
+			if err != nil {
+				return err
+			}
+
+			if err := serializer.Deserialize(data, actual); err != nil {
+				return err
+			}
+		}
+
+		if actual.Id != expected.Id ||
+			actual.Status != expected.Status ||
+			actual.Type != expected.Type ||
+			!bytes.Equal(actual.Payload, expected.Payload) {
+			differences = append(differences, &Difference{
+				Index:     index,
+				Operation: request.Operation,
+				Record:    record,
+				Expected:  expected,
+				Actual:    actual,
+			})
+		}
+
+		return nil
+	})
+
+	return differences, err
+}
+
+// replayWriter discards what is written to the actors of a replay.
+type replayWriter struct {
+	record     *protobuf.TrafficRecord
+	serializer ISerializer
+}
+
+func (writer *replayWriter) IsClosed() bool {
+	return false
+}
+
+func (writer *replayWriter) IsOpen() bool {
+	return true
+}
+
+func (writer *replayWriter) ContentType() string {
+	return writer.record.ContentType
+}
+
+func (writer *replayWriter) Transport() string {
+	return writer.record.Transport
+}
+
+func (writer *replayWriter) SetSecureCookie(_, _ string) {
+}
+
+func (writer *replayWriter) GetSecureCookie(_ string) string {
+	return ""
+}
+
+func (writer *replayWriter) SetAuthCookie(_ string) {
+}
+
+func (writer *replayWriter) GetAuthCookie() string {
+	return writer.record.Token
+}
+
+func (writer *replayWriter) SetToken(_ string) {
+}
+
+func (writer *replayWriter) Write(_ IOperationResult) {
+}
+
+func (writer *replayWriter) WriteByte(_ byte) error {
+	return nil
+}
+
+func (writer *replayWriter) WriteBytes(_ []byte) {
+}
+
+func (writer *replayWriter) End(_ IOperationResult) {
+}
+
+func (writer *replayWriter) Serializer() ISerializer {
+	return writer.serializer
+}
+
+func (writer *replayWriter) Close() {
+}
@@ -0,0 +1,106 @@
+package traffic_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/xeronith/diamante/contracts/service"
+	. "github.com/xeronith/diamante/contracts/system"
+	"github.com/xeronith/diamante/operation"
+	"github.com/xeronith/diamante/protobuf"
+	"github.com/xeronith/diamante/serialization"
+	"github.com/xeronith/diamante/server/servertest"
+	. "github.com/xeronith/diamante/traffic"
+	"google.golang.org/protobuf/proto"
+)
+
+type echoOperation struct {
+	operation.Operation
+	suffix string
+}
+
+func (operation *echoOperation) Tag() string              { return "ECHO" }
+func (operation *echoOperation) Id() (ID, ID)             { return 100, 101 }
+func (operation *echoOperation) InputContainer() Pointer  { return &protobuf.ServerError{} }
+func (operation *echoOperation) OutputContainer() Pointer { return &protobuf.ServerError{} }
+func (operation *echoOperation) IsCacheable() bool        { return false }
+func (operation *echoOperation) Execute(_ IContext, payload Pointer) (Pointer, error) {
+	return &protobuf.ServerError{Message: payload.(*protobuf.ServerError).Message + operation.suffix}, nil
+}
+
+func TestWriter_Rotation(test *testing.T) {
+	path := filepath.Join(test.TempDir(), "traffic.rec")
+	writer := CreateWriter(path, 64, 3)
+
+	for index := 0; index < 10; index++ {
+		if err := writer.Write(&protobuf.TrafficRecord{Token: "token", Request: make([]byte, 20)}); err != nil {
+			test.Fatal(err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		test.Fatal(err)
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		count := 0
+		if err := Read(name, func(record *protobuf.TrafficRecord) error {
+			count++
+			if record.Token != "token" || len(record.Request) != 20 {
+				test.Fail()
+			}
+
+			return nil
+		}); err != nil || count == 0 {
+			test.Fatal(name, err, count)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		test.Fatal(err)
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		test.Fatal(err, info.Mode())
+	}
+}
+
+func TestReplay(test *testing.T) {
+	echo := &echoOperation{}
+	harness := servertest.NewHarness(test, echo)
+	actor := harness.PassiveActor(nil)
+	serializer := serialization.NewProtobufSerializer()
+
+	path := filepath.Join(test.TempDir(), "traffic.rec")
+	writer := CreateWriter(path, 0, 1)
+	requests := make([][]byte, 0)
+	for _, message := range []string{"first", "second"} {
+		payload, _ := proto.Marshal(&protobuf.ServerError{Message: message})
+		request, _ := serializer.Serialize(&protobuf.OperationRequest{Id: 1, Operation: 100, Payload: payload})
+		requests = append(requests, request)
+	}
+
+	requests = append(requests, []byte{0xFF, 0xFF})
+	for _, request := range requests {
+		result, _ := serializer.Serialize(harness.OnData(actor, request).Container())
+		if err := writer.Write(&protobuf.TrafficRecord{Request: request, Result: result}); err != nil {
+			test.Fatal(err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		test.Fatal(err)
+	}
+
+	differences, err := Replay(harness, path)
+	if err != nil || len(differences) != 0 {
+		test.Fatal(err, differences)
+	}
+
+	echo.suffix = "!"
+	differences, err = Replay(harness, path)
+	if err != nil || len(differences) != 2 || differences[1].Index != 1 || differences[1].Operation != 100 {
+		test.Fatal(err, differences)
+	}
+}
@@ -0,0 +1,128 @@
+// Package traffic records the requests a server handles along with their
+// results, and replays the recordings against a server to find the results
+// that have changed.
+package traffic
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/xeronith/diamante/protobuf"
+	"google.golang.org/protobuf/proto"
+)
+
+// Writer appends records to a file, each one framed by its size as a 4 byte
+// big endian header, and rotates the file once it grows past the maximum
+// size. Rotated files get a numeric suffix, the most recent being .1, and
+// only the given number of files, the current one included, are kept. The
+// records hold tokens and payloads, so the files are readable by their owner
+// only.
+type Writer struct {
+	mutex       sync.Mutex
+	path        string
+	maxFileSize int64
+	maxFiles    int
+	file        *os.File
+	size        int64
+}
+
+func CreateWriter(path string, maxFileSize int64, maxFiles int) *Writer {
+	if maxFiles < 1 {
+		maxFiles = 1
+	}
+
+	return &Writer{
+		path:        path,
+		maxFileSize: maxFileSize,
+		maxFiles:    maxFiles,
+	}
+}
+
+func (writer *Writer) Write(record *protobuf.TrafficRecord) error {
+	data, err := proto.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	frame := make([]byte, 4, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	frame = append(frame, data...)
+
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	if writer.file != nil && writer.maxFileSize > 0 && writer.size > 0 && writer.size+int64(len(frame)) > writer.maxFileSize {
+		if err := writer.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if writer.file == nil {
+		if err := writer.open(); err != nil {
+			return err
+		}
+	}
+
+	written, err := writer.file.Write(frame)
+	writer.size += int64(written)
+	return err
+}
+
+func (writer *Writer) Close() error {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	if writer.file == nil {
+		return nil
+	}
+
+	err := writer.file.Close()
+	writer.file = nil
+	return err
+}
+
+func (writer *Writer) open() error {
+	file, err := os.OpenFile(writer.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	writer.file, writer.size = file, info.Size()
+	return nil
+}
+
+func (writer *Writer) rotate() error {
+	if err := writer.file.Close(); err != nil {
+		return err
+	}
+
+	writer.file = nil
+
+	if writer.maxFiles == 1 {
+		return os.Remove(writer.path)
+	}
+
+	if err := os.Remove(rotatedPath(writer.path, writer.maxFiles-1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for index := writer.maxFiles - 2; index > 0; index-- {
+		if err := os.Rename(rotatedPath(writer.path, index), rotatedPath(writer.path, index+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return os.Rename(writer.path, rotatedPath(writer.path, 1))
+}
+
+func rotatedPath(path string, index int) string {
+	return fmt.Sprintf("%s.%d", path, index)
+}
@@ -0,0 +1,25 @@
+package utility
+
+import (
+	"regexp"
+	"strings"
+)
+
+var sqlWriteTarget = regexp.MustCompile(`(?i)\b(?:INSERT\s+INTO|UPDATE|DELETE\s+FROM|TRUNCATE(?:\s+TABLE)?)\s+(?:ONLY\s+)?((?:"[^"]+"|\w+)(?:\s*\.\s*(?:"[^"]+"|\w+))?)`)
+
+// ExtractTableNames returns the distinct tables written by the given sql command.
+func ExtractTableNames(command string) []string {
+	tables := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, match := range sqlWriteTarget.FindAllStringSubmatch(command, -1) {
+		parts := strings.Split(match[1], ".")
+		table := strings.Trim(strings.TrimSpace(parts[len(parts)-1]), `"`)
+		if table != "" && !seen[table] {
+			seen[table] = true
+			tables = append(tables, table)
+		}
+	}
+
+	return tables
+}
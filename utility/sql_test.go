@@ -0,0 +1,22 @@
+package utility_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xeronith/diamante/utility"
+)
+
+func Test_ExtractTableNames(test *testing.T) {
+	for command, expected := range map[string][]string{
+		`INSERT INTO "users" ("id", "name") VALUES ($1, $2);`:                  {"users"},
+		`UPDATE "public"."posts" SET "title" = $1 WHERE "id" = $2;`:            {"posts"},
+		`DELETE FROM comments WHERE "id" = $1;`:                                {"comments"},
+		`UPDATE "users" SET "x" = 1; DELETE FROM "likes"; TRUNCATE TABLE "x";`: {"users", "likes", "x"},
+		`SELECT "id" FROM "users";`:                                            {},
+	} {
+		if actual := utility.ExtractTableNames(command); !reflect.DeepEqual(actual, expected) {
+			test.Errorf("%s: expected %v, got %v", command, expected, actual)
+		}
+	}
+}